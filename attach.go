@@ -0,0 +1,81 @@
+package ramble
+
+// UploadHelloReq is sent by the client as the initial request to upload an
+// attachment. Attachments should be encrypted by the client, and are referred
+// to from messages by the hex SHA-256 hash of their content.
+type UploadHelloReq struct {
+	// Sender's public key fingerprint.
+	Sender string `json:"sender"`
+
+	// Size of the attachment in bytes.
+	Size int64 `json:"size"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// UploadHelloResp is sent by the server in response to UploadHelloReq.
+type UploadHelloResp HelloResponse
+
+// UploadVerifyReq is sent by the client in response to UploadHelloResp.
+type UploadVerifyReq VerifyRequest
+
+// UploadVerifyResp is sent by the server in response to UploadVerifyReq and
+// terminates the hello-verify handshake. The upload then continues with
+// UploadChunkReq.
+type UploadVerifyResp struct {
+	// ChunkSize is the size of each chunk but the last, in bytes.
+	ChunkSize int `json:"chunk_size"`
+
+	// Upload ID to be passed to chunk requests.
+	Upload string `json:"upload"`
+}
+
+// UploadChunkReq is sent by the client to upload the next chunk of an
+// attachment. A request without data asks for the offset to resume from, also
+// after the server restarts. Uploads which are not continued within the
+// handshake duration are abandoned.
+type UploadChunkReq struct {
+	// Data of the chunk.
+	Data []byte `json:"data"`
+
+	// Offset of the chunk, which must be the offset of the previous
+	// response.
+	Offset int64 `json:"offset"`
+
+	// Upload ID from the verify response.
+	Upload string `json:"upload"`
+}
+
+// UploadChunkResp is sent by the server in response to UploadChunkReq.
+type UploadChunkResp struct {
+	// Hash of the attachment, set once all its data is uploaded. The
+	// attachment is removed unless a message refers to it within the
+	// handshake duration.
+	Hash string `json:"hash,omitempty"`
+
+	// Offset of the next chunk.
+	Offset int64 `json:"offset"`
+}
+
+// DownloadReq is sent by the client to read part of an attachment.
+type DownloadReq struct {
+	// Hash of the attachment.
+	Hash string `json:"hash"`
+
+	// Length to read, at most one chunk, or 0 for one chunk.
+	Length int64 `json:"length"`
+
+	// Offset to read from.
+	Offset int64 `json:"offset"`
+}
+
+// DownloadResp is sent by the server in response to DownloadReq.
+type DownloadResp struct {
+	// Data read, which is shorter than the requested length at the end of
+	// the attachment.
+	Data []byte `json:"data"`
+
+	// Size of the attachment in bytes.
+	Size int64 `json:"size"`
+}
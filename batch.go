@@ -0,0 +1,50 @@
+package ramble
+
+// BatchSendHelloReq is sent by the client as the initial request to append many
+// messages with one hello-verify handshake. Sealed senders cannot send batches.
+type BatchSendHelloReq struct {
+	// Items to send, each checked as a SendHelloReq from the sender.
+	Items []BatchSendItem `json:"items"`
+
+	// Sender's public key fingerprint.
+	Sender string `json:"sender"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// BatchSendItem is one message of a BatchSendHelloReq. See SendHelloReq for
+// field documentation.
+type BatchSendItem struct {
+	Attachments  []string `json:"attachments,omitempty"`
+	Conversation string   `json:"conv"`
+	Mailboxes    []string `json:"mailboxes,omitempty"`
+	Message      string   `json:"msg"`
+	Recipients   []string `json:"recipients"`
+}
+
+// BatchSendHelloResp is sent by the server in response to BatchSendHelloReq.
+type BatchSendHelloResp HelloResponse
+
+// BatchSendVerifyReq is sent by the client in response to BatchSendHelloResp.
+// The one signature authorizes every item of the batch.
+type BatchSendVerifyReq VerifyRequest
+
+// BatchSendVerifyResp is sent by the server in response to BatchSendVerifyReq
+// and terminates the hello-verify handshake.
+type BatchSendVerifyResp struct {
+	// Results of each item, in the order of the hello request items.
+	Results []BatchSendResult `json:"results"`
+}
+
+// BatchSendResult is the result of one BatchSendItem.
+type BatchSendResult struct {
+	// Conversation UUID the message was sent to, as in SendVerifyResp.
+	Conversation string `json:"conv,omitempty"`
+
+	// Credential of a created conversation, as in SendVerifyResp.
+	Credential string `json:"credential,omitempty"`
+
+	// Error is set if the message was not sent.
+	Error string `json:"error,omitempty"`
+}
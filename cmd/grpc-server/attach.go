@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (r *rambleServer) UploadHello(ctx context.Context, in *rpc.UploadHelloReq) (*rpc.HelloResponse, error) {
+	resp, err := r.srv.UploadHello(&ramble.UploadHelloReq{
+		Sender:  in.Sender,
+		Size:    in.Size,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) UploadVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.UploadVerifyResp, error) {
+	req := ramble.UploadVerifyReq(verifyRequest(in))
+	resp, err := r.srv.UploadVerify(&req)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	return &rpc.UploadVerifyResp{
+		ChunkSize: int64(resp.ChunkSize),
+		Upload:    resp.Upload,
+	}, nil
+}
+
+func (r *rambleServer) UploadChunk(ctx context.Context, in *rpc.UploadChunkReq) (*rpc.UploadChunkResp, error) {
+	resp, err := r.srv.UploadChunk(&ramble.UploadChunkReq{
+		Data:   in.Data,
+		Offset: in.Offset,
+		Upload: in.Upload,
+	})
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	return &rpc.UploadChunkResp{
+		Hash:   resp.Hash,
+		Offset: resp.Offset,
+	}, nil
+}
+
+func (r *rambleServer) Download(ctx context.Context, in *rpc.DownloadReq) (*rpc.DownloadResp, error) {
+	resp, err := r.srv.Download(&ramble.DownloadReq{
+		Hash:   in.Hash,
+		Length: in.Length,
+		Offset: in.Offset,
+	})
+
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	return &rpc.DownloadResp{
+		Data: resp.Data,
+		Size: resp.Size,
+	}, nil
+}
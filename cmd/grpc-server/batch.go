@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/rpc"
+)
+
+func (r *rambleServer) BatchHello(ctx context.Context, in *rpc.BatchSendHelloReq) (*rpc.HelloResponse, error) {
+	items := make([]ramble.BatchSendItem, len(in.Items))
+
+	for i, item := range in.Items {
+		items[i] = ramble.BatchSendItem{
+			Attachments:  item.Attachments,
+			Conversation: item.Conv,
+			Mailboxes:    item.Mailboxes,
+			Message:      item.Msg,
+			Recipients:   item.Recipients,
+		}
+	}
+
+	resp, err := r.srv.BatchSendHello(&ramble.BatchSendHelloReq{
+		Items:   items,
+		Sender:  in.Sender,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) BatchVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.BatchSendVerifyResp, error) {
+	req := ramble.BatchSendVerifyReq(verifyRequest(in))
+	resp, err := r.srv.BatchSendVerify(&req)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	results := make([]*rpc.BatchSendResult, len(resp.Results))
+
+	for i, result := range resp.Results {
+		results[i] = &rpc.BatchSendResult{
+			Conv:       result.Conversation,
+			Credential: result.Credential,
+			Error:      result.Error,
+		}
+	}
+
+	return &rpc.BatchSendVerifyResp{
+		Results: results,
+	}, nil
+}
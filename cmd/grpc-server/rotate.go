@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/rpc"
+)
+
+func (r *rambleServer) RotateHello(ctx context.Context, in *rpc.RotateHelloReq) (*rpc.HelloResponse, error) {
+	resp, err := r.srv.RotateHello(&ramble.RotateHelloReq{
+		Public:  in.Public,
+		Sender:  in.Sender,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) RotateVerify(ctx context.Context, in *rpc.RotateVerifyReq) (*rpc.RotateVerifyResp, error) {
+	resp, err := r.srv.RotateVerify(&ramble.RotateVerifyReq{
+		NewSignature: in.NewSig,
+		Signature:    in.Sig,
+		UUID:         in.Uuid,
+	})
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	return &rpc.RotateVerifyResp{
+		Fingerprint: resp.Fingerprint,
+	}, nil
+}
+
+func (r *rambleServer) Lookup(ctx context.Context, in *rpc.LookupReq) (*rpc.LookupResp, error) {
+	resp, err := r.srv.Lookup(&ramble.LookupReq{
+		Fingerprint: in.Fingerprint,
+		Version:     in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return &rpc.LookupResp{
+		Fingerprint: resp.Fingerprint,
+	}, nil
+}
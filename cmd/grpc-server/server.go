@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/internal/pgp"
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/pkg/rpc"
+	"github.com/esote/ramble/pkg/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	backend := flag.String("backend", server.BackendFiles, "storage"+
+		" backend, "+server.BackendFiles+" or "+server.BackendBolt)
+	keyfile := flag.String("keyfile", "", "file of hex master keys used to"+
+		" encrypt data at rest, current key first (default $"+
+		store.MasterKeyEnv+")")
+	flag.Parse()
+
+	keys, err := store.LoadMasterKeys(*keyfile)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv, err := server.NewServer(&server.Config{
+		Backend:           *backend,
+		Dur:               time.Hour,
+		MasterKeys:        keys,
+		MaxAttachmentSize: 64 << 20,
+		MaxBatch:          100,
+		MaxCount:          1000,
+		MaxMessageSize:    1 << 20,
+		PaddingBuckets:    pgp.DefaultBuckets,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := grpc.NewServer()
+	rpc.RegisterRambleServer(g, &rambleServer{srv: srv})
+	log.Fatal(g.Serve(l))
+}
+
+// Errors are not detailed, matching the HTTP server.
+var errBadRequest = status.Error(codes.InvalidArgument, "bad request")
+
+// Request types served over gRPC, sorted. They are named by the methods of the
+// service, without the suffix of their step.
+var requests = serviceRequests(rpc.Ramble_ServiceDesc)
+
+// Gets the error of a failed hello request. Errors are not detailed, except for
+// protocol version mismatches so clients know to upgrade.
+func helloError(err error) error {
+	var v *server.VersionError
+
+	if errors.As(err, &v) {
+		return status.Error(codes.FailedPrecondition, v.Error())
+	}
+
+	return errBadRequest
+}
+
+// Adapts a server.Server to the gRPC service.
+type rambleServer struct {
+	rpc.UnimplementedRambleServer
+
+	srv *server.Server
+}
+
+func helloResponse(h *ramble.HelloResponse) *rpc.HelloResponse {
+	return &rpc.HelloResponse{
+		Nonce: h.Nonce,
+		Uuid:  h.UUID,
+	}
+}
+
+func verifyRequest(v *rpc.VerifyRequest) ramble.VerifyRequest {
+	return ramble.VerifyRequest{
+		Signature: v.Sig,
+		UUID:      v.Uuid,
+	}
+}
+
+func requestType(t uint32) (uint8, error) {
+	if t > math.MaxUint8 {
+		return 0, errors.New("type out of range")
+	}
+
+	return uint8(t), nil
+}
+
+func (r *rambleServer) WelcomeHello(ctx context.Context, in *rpc.WelcomeHelloReq) (*rpc.HelloResponse, error) {
+	resp, err := r.srv.WelcomeHello(&ramble.WelcomeHelloReq{
+		Public:  in.Public,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) WelcomeVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.WelcomeVerifyResp, error) {
+	req := ramble.WelcomeVerifyReq(verifyRequest(in))
+
+	if _, err := r.srv.WelcomeVerify(&req); err != nil {
+		return nil, errBadRequest
+	}
+
+	return new(rpc.WelcomeVerifyResp), nil
+}
+
+func (r *rambleServer) SendHello(ctx context.Context, in *rpc.SendHelloReq) (*rpc.HelloResponse, error) {
+	resp, err := r.srv.SendHello(&ramble.SendHelloReq{
+		Attachments:  in.Attachments,
+		Conversation: in.Conv,
+		Mailboxes:    in.Mailboxes,
+		Message:      in.Msg,
+		Recipients:   in.Recipients,
+		Sender:       in.Sender,
+		Version:      in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) SendVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.SendVerifyResp, error) {
+	req := ramble.SendVerifyReq(verifyRequest(in))
+	resp, err := r.srv.SendVerify(&req)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	return &rpc.SendVerifyResp{
+		Conv:       resp.Conversation,
+		Credential: resp.Credential,
+	}, nil
+}
+
+func (r *rambleServer) ViewHello(ctx context.Context, in *rpc.ViewHelloReq) (*rpc.HelloResponse, error) {
+	t, err := requestType(in.Type)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	resp, err := r.srv.ViewHello(&ramble.ViewHelloReq{
+		Count:   in.Count,
+		Mailbox: in.Mailbox,
+		Sender:  in.Sender,
+		Type:    t,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) ViewVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.ViewVerifyResp, error) {
+	req := ramble.ViewVerifyReq(verifyRequest(in))
+	resp, err := r.srv.ViewVerify(&req)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	return &rpc.ViewVerifyResp{
+		List: resp.List,
+	}, nil
+}
+
+func (r *rambleServer) DeleteHello(ctx context.Context, in *rpc.DeleteHelloReq) (*rpc.HelloResponse, error) {
+	t, err := requestType(in.Type)
+
+	if err != nil {
+		return nil, errBadRequest
+	}
+
+	resp, err := r.srv.DeleteHello(&ramble.DeleteHelloReq{
+		Mailbox: in.Mailbox,
+		Sender:  in.Sender,
+		Type:    t,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+func (r *rambleServer) DeleteVerify(ctx context.Context, in *rpc.VerifyRequest) (*rpc.DeleteVerifyResp, error) {
+	req := ramble.DeleteVerifyReq(verifyRequest(in))
+
+	if _, err := r.srv.DeleteVerify(&req); err != nil {
+		return nil, errBadRequest
+	}
+
+	return new(rpc.DeleteVerifyResp), nil
+}
+
+func (r *rambleServer) Info(ctx context.Context, in *rpc.InfoReq) (*rpc.InfoResp, error) {
+	info := r.srv.Info(requests)
+
+	return &rpc.InfoResp{
+		Algorithms: info.Algorithms,
+		Limits: &rpc.Limits{
+			ChunkSize:         int64(info.Limits.ChunkSize),
+			HandshakeTtl:      info.Limits.HandshakeTTL,
+			MaxAttachmentSize: info.Limits.MaxAttachmentSize,
+			MaxBatch:          int64(info.Limits.MaxBatch),
+			MaxCount:          info.Limits.MaxCount,
+			MaxMsgSize:        int64(info.Limits.MaxMessageSize),
+			SessionTtl:        info.Limits.SessionTTL,
+		},
+		Requests: info.Requests,
+		Version:  info.Version,
+	}, nil
+}
+
+// Gets the request types of a service, sorted.
+func serviceRequests(desc grpc.ServiceDesc) []string {
+	var names []string
+
+	for _, m := range desc.Methods {
+		names = append(names, m.MethodName)
+	}
+
+	for _, st := range desc.Streams {
+		names = append(names, st.StreamName)
+	}
+
+	served := make(map[string]bool)
+
+	for _, name := range names {
+		for _, step := range []string{"Chunk", "Hello", "Verify"} {
+			name = strings.TrimSuffix(name, step)
+		}
+
+		served[strings.ToLower(name)] = true
+	}
+
+	return server.ServedRequests(func(request string) bool {
+		return served[request]
+	})
+}
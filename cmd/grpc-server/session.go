@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/rpc"
+	"github.com/esote/ramble/pkg/server"
+	"google.golang.org/grpc/status"
+)
+
+// Serves a session as the HTTP server does over a WebSocket. The first two
+// frames are the hello and verify handshake, after which requests are answered
+// as they arrive and notices are pushed. Frames are only sent from the handler,
+// as a stream must not be sent to once its handler returns.
+func (r *rambleServer) Session(stream rpc.Ramble_SessionServer) error {
+	sess, err := r.openSession(stream)
+
+	if err != nil {
+		return err
+	}
+
+	defer sess.Close()
+
+	frames := make(chan *rpc.Frame)
+	errc := make(chan error, 1)
+
+	go func() {
+		for {
+			f, err := stream.Recv()
+
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case frames <- f:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		var resp rpc.Frame
+
+		select {
+		case f := <-frames:
+			resp.Id = f.Id
+			resp.Type = f.Type
+
+			if resp.Body, err = sessionRequest(sess, f); err != nil {
+				resp.Error = frameError(err)
+			}
+		case notice, ok := <-sess.Notices():
+			if !ok {
+				return nil
+			}
+
+			if resp.Body, err = json.Marshal(notice); err != nil {
+				return err
+			}
+
+			resp.Type = ramble.FrameNotice
+		case err = <-errc:
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		if err = stream.Send(&resp); err != nil {
+			return err
+		}
+	}
+}
+
+// Gets the error of a failed frame, detailed as for hello requests.
+func frameError(err error) string {
+	return status.Convert(helloError(err)).Message()
+}
+
+// Performs the session handshake.
+func (r *rambleServer) openSession(stream rpc.Ramble_SessionServer) (*server.Session, error) {
+	f, err := stream.Recv()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Type != ramble.FrameHello {
+		return nil, errors.New("expected hello frame")
+	}
+
+	var hello ramble.SessionHelloReq
+
+	if err = json.Unmarshal(f.Body, &hello); err != nil {
+		return nil, err
+	}
+
+	resp := rpc.Frame{
+		Id:   f.Id,
+		Type: f.Type,
+	}
+
+	helloResp, err := r.srv.SessionHello(&hello)
+
+	if err != nil {
+		resp.Error = frameError(err)
+		_ = stream.Send(&resp)
+		return nil, err
+	}
+
+	if resp.Body, err = json.Marshal(helloResp); err != nil {
+		return nil, err
+	}
+
+	if err = stream.Send(&resp); err != nil {
+		return nil, err
+	}
+
+	if f, err = stream.Recv(); err != nil {
+		return nil, err
+	}
+
+	if f.Type != ramble.FrameVerify {
+		return nil, errors.New("expected verify frame")
+	}
+
+	var verify ramble.SessionVerifyReq
+
+	if err = json.Unmarshal(f.Body, &verify); err != nil {
+		return nil, err
+	}
+
+	resp = rpc.Frame{
+		Id:   f.Id,
+		Type: f.Type,
+	}
+
+	sess, err := r.srv.SessionVerify(&verify)
+
+	if err != nil {
+		resp.Error = "bad request"
+		_ = stream.Send(&resp)
+		return nil, err
+	}
+
+	if err = stream.Send(&resp); err != nil {
+		sess.Close()
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// Performs a session request, returning the response body.
+func sessionRequest(sess *server.Session, f *rpc.Frame) ([]byte, error) {
+	var resp interface{}
+	var err error
+
+	switch f.Type {
+	case ramble.FrameSend:
+		var req ramble.SendHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		resp, err = sess.Send(&req)
+	case ramble.FrameView:
+		var req ramble.ViewHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		resp, err = sess.View(&req)
+	case ramble.FrameDelete:
+		var req ramble.DeleteHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		// Delete responses have no body.
+		_, err = sess.Delete(&req)
+
+		return nil, err
+	default:
+		return nil, errors.New("unknown frame type")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resp)
+}
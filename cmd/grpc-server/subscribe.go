@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/rpc"
+)
+
+func (r *rambleServer) SubscribeHello(ctx context.Context, in *rpc.SubscribeHelloReq) (*rpc.HelloResponse, error) {
+	resp, err := r.srv.SubscribeHello(&ramble.SubscribeHelloReq{
+		Mailbox: in.Mailbox,
+		Sender:  in.Sender,
+		Version: in.Version,
+	})
+
+	if err != nil {
+		return nil, helloError(err)
+	}
+
+	return helloResponse((*ramble.HelloResponse)(resp)), nil
+}
+
+// Streams notices until the client disconnects.
+func (r *rambleServer) SubscribeVerify(in *rpc.VerifyRequest, stream rpc.Ramble_SubscribeVerifyServer) error {
+	req := ramble.SubscribeVerifyReq(verifyRequest(in))
+	sub, err := r.srv.SubscribeVerify(&req)
+
+	if err != nil {
+		return errBadRequest
+	}
+
+	defer sub.Close()
+
+	for {
+		select {
+		case notice, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+
+			err = stream.Send(&rpc.Notice{
+				Conv: notice.Conversation,
+				Seq:  notice.Sequence,
+			})
+
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
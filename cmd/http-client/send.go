@@ -20,7 +20,8 @@ func sendHello() (uuid string, err error) {
 	}
 
 	req := ramble.SendHelloReq{
-		Sender: strings.TrimSpace(b.String()),
+		Sender:  strings.TrimSpace(b.String()),
+		Version: ramble.Version,
 	}
 
 	b.Reset()
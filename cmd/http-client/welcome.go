@@ -19,7 +19,8 @@ func welcomeHello() (uuid string, err error) {
 	}
 
 	req := ramble.WelcomeHelloReq{
-		Public: b.String(),
+		Public:  b.String(),
+		Version: ramble.Version,
 	}
 
 	data, err := json.Marshal(&req)
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/esote/ramble"
+)
+
+func handleUploadHello(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.UploadHelloReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.UploadHello(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+func handleUploadVerify(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.UploadVerifyReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.UploadVerify(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+// Chunks are sent as base64 within JSON.
+const maxChunkBody = 1 << 20
+
+func handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxChunkBody))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.UploadChunkReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.UploadChunk(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+// Serves attachments at /attachment/<hash>, with support for range requests.
+func handleAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	a, err := srv.OpenAttachment(path.Base(r.URL.Path))
+
+	if err != nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, a)
+}
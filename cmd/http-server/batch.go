@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/esote/ramble"
+)
+
+func handleBatchSendHello(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.BatchSendHelloReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.BatchSendHello(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+func handleBatchSendVerify(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.BatchSendVerifyReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.BatchSendVerify(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
@@ -26,7 +26,7 @@ func handleDeleteHello(w http.ResponseWriter, r *http.Request) {
 	resp, err := srv.DeleteHello(&req)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeRequestError(w, err)
 		return
 	}
 
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/esote/ramble/pkg/server"
+)
+
+// Request types served, sorted.
+var requests []string
+
+// Request types served outside of routes, by path.
+var otherPaths = map[string]string{
+	"/attachment/": "download",
+	"/session":     "session",
+}
+
+// Gets the request types served, which are named by the first element of the
+// paths of routes, or by otherPaths.
+func servedRequests() []string {
+	served := make(map[string]bool)
+
+	for path := range routes {
+		served[strings.Split(strings.TrimPrefix(path, "/"), "/")[0]] = true
+	}
+
+	for _, name := range otherPaths {
+		served[name] = true
+	}
+
+	return server.ServedRequests(func(request string) bool {
+		return served[request]
+	})
+}
+
+func handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, err := json.Marshal(srv.Info(requests))
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/esote/ramble"
+)
+
+func handleRotateHello(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.RotateHelloReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.RotateHello(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+func handleRotateVerify(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.RotateVerifyReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.RotateVerify(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+func handleLookup(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.LookupReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.Lookup(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
@@ -26,7 +26,7 @@ func handleSendHello(w http.ResponseWriter, r *http.Request) {
 	resp, err := srv.SendHello(&req)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeRequestError(w, err)
 		return
 	}
 
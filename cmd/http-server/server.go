@@ -1,25 +1,57 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"path/filepath"
 	"time"
 
+	"github.com/esote/ramble/internal/pgp"
+	"github.com/esote/ramble/internal/store"
 	"github.com/esote/ramble/pkg/server"
+	"golang.org/x/net/websocket"
 )
 
 var srv *server.Server
 
 func main() {
-	var err error
-	srv, err = server.NewServer(time.Hour)
+	backend := flag.String("backend", server.BackendFiles, "storage"+
+		" backend, "+server.BackendFiles+" or "+server.BackendBolt)
+	keyfile := flag.String("keyfile", "", "file of hex master keys used to"+
+		" encrypt data at rest, current key first (default $"+
+		store.MasterKeyEnv+")")
+	flag.Parse()
+
+	keys, err := store.LoadMasterKeys(*keyfile)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv, err = server.NewServer(&server.Config{
+		Backend:           *backend,
+		Dur:               time.Hour,
+		MasterKeys:        keys,
+		MaxAttachmentSize: 64 << 20,
+		MaxBatch:          100,
+		MaxCount:          1000,
+		MaxMessageSize:    1 << 20,
+		PaddingBuckets:    pgp.DefaultBuckets,
+	})
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	http.HandleFunc("/attachment/", handleAttachment)
+	http.HandleFunc("/info", handleInfo)
+	http.Handle("/session", websocket.Handler(handleSession))
 	http.HandleFunc("/", handler)
+
+	requests = servedRequests()
+
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
 
@@ -27,32 +59,57 @@ func writeError(w http.ResponseWriter, status int) {
 	http.Error(w, http.StatusText(status), status)
 }
 
+// Gets the description of a request error shown to clients. Errors are not
+// detailed, except for protocol version mismatches so clients know to upgrade.
+func errorDetail(err error, fallback string) string {
+	var v *server.VersionError
+
+	if errors.As(err, &v) {
+		return v.Error()
+	}
+
+	return fallback
+}
+
+func writeRequestError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	http.Error(w, errorDetail(err, http.StatusText(status)), status)
+}
+
+// Handlers of POST requests by path.
+var routes = map[string]http.HandlerFunc{
+	"/batch/hello":      handleBatchSendHello,
+	"/batch/verify":     handleBatchSendVerify,
+	"/delete/hello":     handleDeleteHello,
+	"/delete/verify":    handleDeleteVerify,
+	"/lookup":           handleLookup,
+	"/rotate/hello":     handleRotateHello,
+	"/rotate/verify":    handleRotateVerify,
+	"/send/hello":       handleSendHello,
+	"/send/verify":      handleSendVerify,
+	"/subscribe/hello":  handleSubscribeHello,
+	"/subscribe/verify": handleSubscribeVerify,
+	"/upload/chunk":     handleUploadChunk,
+	"/upload/hello":     handleUploadHello,
+	"/upload/verify":    handleUploadVerify,
+	"/view/hello":       handleViewHello,
+	"/view/verify":      handleViewVerify,
+	"/welcome/hello":    handleWelcomeHello,
+	"/welcome/verify":   handleWelcomeVerify,
+}
+
 func handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed)
 		return
 	}
 
-	path := filepath.Clean(r.URL.Path)
-
-	switch path {
-	case "/delete/hello":
-		handleDeleteHello(w, r)
-	case "/delete/verify":
-		handleDeleteVerify(w, r)
-	case "/send/hello":
-		handleSendHello(w, r)
-	case "/send/verify":
-		handleSendVerify(w, r)
-	case "/view/hello":
-		handleViewHello(w, r)
-	case "/view/verify":
-		handleViewVerify(w, r)
-	case "/welcome/hello":
-		handleWelcomeHello(w, r)
-	case "/welcome/verify":
-		handleWelcomeVerify(w, r)
-	default:
+	h, ok := routes[filepath.Clean(r.URL.Path)]
+
+	if !ok {
 		writeError(w, http.StatusNotFound)
+		return
 	}
+
+	h(w, r)
 }
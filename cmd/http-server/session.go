@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/pkg/server"
+	"golang.org/x/net/websocket"
+)
+
+// Serves a session over a WebSocket. The first two frames are the hello and
+// verify handshake, after which requests are answered as they arrive and
+// notices are pushed.
+func handleSession(ws *websocket.Conn) {
+	defer ws.Close()
+
+	var mu sync.Mutex
+
+	send := func(f *ramble.Frame) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return websocket.JSON.Send(ws, f)
+	}
+
+	sess, err := openSession(ws, send)
+
+	if err != nil {
+		return
+	}
+
+	defer sess.Close()
+
+	go func() {
+		// The connection is closed once the session ends.
+		defer ws.Close()
+
+		for notice := range sess.Notices() {
+			b, err := json.Marshal(notice)
+
+			if err != nil {
+				return
+			}
+
+			f := ramble.Frame{
+				Body: b,
+				Type: ramble.FrameNotice,
+			}
+
+			if send(&f) != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var f ramble.Frame
+
+		if websocket.JSON.Receive(ws, &f) != nil {
+			return
+		}
+
+		resp := ramble.Frame{
+			ID:   f.ID,
+			Type: f.Type,
+		}
+
+		if resp.Body, err = sessionRequest(sess, &f); err != nil {
+			resp.Error = errorDetail(err, "bad request")
+		}
+
+		if send(&resp) != nil {
+			return
+		}
+	}
+}
+
+// Performs the session handshake.
+func openSession(ws *websocket.Conn, send func(*ramble.Frame) error) (*server.Session, error) {
+	var f ramble.Frame
+
+	if err := websocket.JSON.Receive(ws, &f); err != nil {
+		return nil, err
+	}
+
+	if f.Type != ramble.FrameHello {
+		return nil, errors.New("expected hello frame")
+	}
+
+	var hello ramble.SessionHelloReq
+
+	if err := json.Unmarshal(f.Body, &hello); err != nil {
+		return nil, err
+	}
+
+	helloResp, err := srv.SessionHello(&hello)
+
+	if err != nil {
+		_ = send(&ramble.Frame{
+			Error: errorDetail(err, "bad request"),
+			ID:    f.ID,
+			Type:  f.Type,
+		})
+		return nil, err
+	}
+
+	b, err := json.Marshal(helloResp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = send(&ramble.Frame{
+		Body: b,
+		ID:   f.ID,
+		Type: f.Type,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = websocket.JSON.Receive(ws, &f); err != nil {
+		return nil, err
+	}
+
+	if f.Type != ramble.FrameVerify {
+		return nil, errors.New("expected verify frame")
+	}
+
+	var verify ramble.SessionVerifyReq
+
+	if err = json.Unmarshal(f.Body, &verify); err != nil {
+		return nil, err
+	}
+
+	resp := ramble.Frame{
+		ID:   f.ID,
+		Type: f.Type,
+	}
+
+	sess, err := srv.SessionVerify(&verify)
+
+	if err != nil {
+		resp.Error = "bad request"
+		_ = send(&resp)
+		return nil, err
+	}
+
+	if err = send(&resp); err != nil {
+		sess.Close()
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// Performs a session request, returning the response body.
+func sessionRequest(sess *server.Session, f *ramble.Frame) (json.RawMessage, error) {
+	var resp interface{}
+	var err error
+
+	switch f.Type {
+	case ramble.FrameSend:
+		var req ramble.SendHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		resp, err = sess.Send(&req)
+	case ramble.FrameView:
+		var req ramble.ViewHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		resp, err = sess.View(&req)
+	case ramble.FrameDelete:
+		var req ramble.DeleteHelloReq
+
+		if err = json.Unmarshal(f.Body, &req); err != nil {
+			return nil, err
+		}
+
+		// Delete responses have no body.
+		_, err = sess.Delete(&req)
+
+		return nil, err
+	default:
+		return nil, errors.New("unknown frame type")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resp)
+}
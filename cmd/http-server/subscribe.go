@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/esote/ramble"
+)
+
+// Interval between comments sent to keep idle streams open.
+const keepAlive = 30 * time.Second
+
+func handleSubscribeHello(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.SubscribeHelloReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := srv.SubscribeHello(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	if b, err = json.Marshal(resp); err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(b)
+}
+
+// Streams notices as server-sent events until the client disconnects.
+func handleSubscribeVerify(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	var req ramble.SubscribeVerifyReq
+
+	if json.Unmarshal(b, &req) != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+
+	sub, err := srv.SubscribeVerify(&req)
+
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err = w.Write([]byte(":\n\n")); err != nil {
+				return
+			}
+		case notice := <-sub.C:
+			if b, err = json.Marshal(notice); err != nil {
+				return
+			}
+
+			b = append(append([]byte("data: "), b...), "\n\n"...)
+
+			if _, err = w.Write(b); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
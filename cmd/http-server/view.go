@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 
@@ -26,7 +28,7 @@ func handleViewHello(w http.ResponseWriter, r *http.Request) {
 	resp, err := srv.ViewHello(&req)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeRequestError(w, err)
 		return
 	}
 
@@ -38,6 +40,7 @@ func handleViewHello(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(b)
 }
 
+// Streams the response, so that large lists are not held in memory.
 func handleViewVerify(w http.ResponseWriter, r *http.Request) {
 	b, err := ioutil.ReadAll(r.Body)
 
@@ -53,17 +56,81 @@ func handleViewVerify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := srv.ViewVerify(&req)
+	list := &listWriter{
+		w: w,
+	}
+
+	err = srv.ViewVerifyTo(&req, list)
 
-	if err != nil {
+	if err != nil && !list.started {
 		writeError(w, http.StatusBadRequest)
 		return
 	}
 
-	if b, err = json.Marshal(resp); err != nil {
-		writeError(w, http.StatusInternalServerError)
-		return
+	if err == nil {
+		err = list.Close()
 	}
 
-	_, _ = w.Write(b)
+	// The response is cut short, so the client cannot mistake it for a
+	// whole list.
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Writes a view response as JSON, quoting the list as it is written. The
+// response is started by the first write and finished by Close.
+type listWriter struct {
+	buf     []byte
+	started bool
+	w       io.Writer
+}
+
+func (l *listWriter) start() error {
+	if l.started {
+		return nil
+	}
+
+	l.started = true
+	_, err := io.WriteString(l.w, `{"list":"`)
+
+	return err
+}
+
+func (l *listWriter) Write(p []byte) (int, error) {
+	if err := l.start(); err != nil {
+		return 0, err
+	}
+
+	l.buf = l.buf[:0]
+
+	for _, c := range p {
+		switch {
+		case c == '"', c == '\\':
+			l.buf = append(l.buf, '\\', c)
+		case c == '\n':
+			l.buf = append(l.buf, '\\', 'n')
+		case c < 0x20:
+			l.buf = append(l.buf, fmt.Sprintf(`\u%04x`, c)...)
+		default:
+			l.buf = append(l.buf, c)
+		}
+	}
+
+	if _, err := l.w.Write(l.buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close finishes the response, writing an empty list if nothing was written.
+func (l *listWriter) Close() error {
+	if err := l.start(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(l.w, `"}`)
+
+	return err
 }
@@ -26,7 +26,7 @@ func handleWelcomeHello(w http.ResponseWriter, r *http.Request) {
 	resp, err := srv.WelcomeHello(&req)
 
 	if err != nil {
-		writeError(w, http.StatusBadRequest)
+		writeRequestError(w, err)
 		return
 	}
 
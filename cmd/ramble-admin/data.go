@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/pkg/server"
+)
+
+// Flags locating a server's data.
+type dataFlags struct {
+	backend *string
+	dir     *string
+	keyfile *string
+}
+
+func addDataFlags(fs *flag.FlagSet) *dataFlags {
+	return &dataFlags{
+		backend: fs.String("backend", server.BackendFiles, "storage"+
+			" backend, "+server.BackendFiles+" or "+
+			server.BackendBolt),
+		dir: fs.String("dir", ".", "data directory"),
+		keyfile: fs.String("keyfile", "", "file of hex master keys the"+
+			" data is encrypted with, current key first (default $"+
+			store.MasterKeyEnv+")"),
+	}
+}
+
+// Opens the data as a server, so it is read and changed as the server would.
+// Data opened read-only is left as it is, and may be open in other read-only
+// servers at the same time.
+func (d *dataFlags) open(readOnly bool) (*server.Server, error) {
+	if _, err := os.Stat(*d.dir); err != nil {
+		return nil, err
+	}
+
+	keys, err := store.LoadMasterKeys(*d.keyfile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return server.NewServer(&server.Config{
+		Backend:    *d.backend,
+		Dir:        *d.dir,
+		Dur:        time.Hour,
+		MasterKeys: keys,
+		ReadOnly:   readOnly,
+	})
+}
+
+// Parses a command's flags and opens its data, checking it was given n
+// arguments.
+func parseData(name, args string, n int, readOnly bool, argv []string) (*server.Server, []string, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: ramble-admin %s [flags] %s\n",
+			name, args)
+		fs.PrintDefaults()
+	}
+
+	data := addDataFlags(fs)
+	_ = fs.Parse(argv)
+
+	if n >= 0 && fs.NArg() != n {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	srv, err := data.open(readOnly)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return srv, fs.Args(), nil
+}
+
+// Lists the fingerprints of registered keys.
+func list(argv []string) error {
+	srv, _, err := parseData("list", "", 0, true, argv)
+
+	if err != nil {
+		return err
+	}
+
+	defer srv.Close()
+
+	fingerprints, err := srv.Fingerprints()
+
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fingerprints {
+		fmt.Println(f)
+	}
+
+	return nil
+}
+
+// Shows the number of conversations of each user, or of the users given.
+func convos(argv []string) error {
+	srv, fingerprints, err := parseData("convos", "[fingerprint ...]", -1,
+		true, argv)
+
+	if err != nil {
+		return err
+	}
+
+	defer srv.Close()
+
+	if len(fingerprints) == 0 {
+		if fingerprints, err = srv.Fingerprints(); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fingerprints {
+		c, err := srv.Conversations(f)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s %d\n", f, len(c))
+	}
+
+	return nil
+}
+
+// Shows the UUIDs and sizes of a conversation's messages.
+func inspect(argv []string) error {
+	srv, args, err := parseData("inspect", "conversation", 1, true,
+		argv)
+
+	if err != nil {
+		return err
+	}
+
+	defer srv.Close()
+
+	msgs, err := srv.Messages(args[0])
+
+	if os.IsNotExist(err) {
+		return errors.New("no conversation with UUID")
+	} else if err != nil {
+		return err
+	}
+
+	total := 0
+
+	for _, m := range msgs {
+		fmt.Printf("%s %d\n", m.UUID, m.Size)
+		total += m.Size
+	}
+
+	fmt.Printf("%d messages, %d bytes\n", len(msgs), total)
+
+	return nil
+}
+
+// Removes a user's key and list of conversations.
+func purge(argv []string) error {
+	srv, args, err := parseData("purge", "fingerprint", 1, false, argv)
+
+	if err != nil {
+		return err
+	}
+
+	defer srv.Close()
+
+	if err = srv.Purge(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("purged %s\n", args[0])
+
+	return nil
+}
+
+// Reclaims space left by removed data.
+func compact(argv []string) error {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	data := addDataFlags(fs)
+	_ = fs.Parse(argv)
+
+	unlock, err := lockDir(*data.dir, false)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	backend, err := openBackend(*data.backend, *data.dir, false)
+
+	if err != nil {
+		return err
+	}
+
+	defer backend.Close()
+
+	before, err := dirSize(*data.dir)
+
+	if err != nil {
+		return err
+	}
+
+	if err = backend.Compact(); err != nil {
+		return err
+	}
+
+	after, err := dirSize(*data.dir)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("compacted %d bytes to %d bytes\n", before, after)
+
+	return nil
+}
+
+// Gets the size of the files in a directory.
+func dirSize(dir string) (size int64, err error) {
+	err = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// Rewrites data encrypted at rest under old master keys so that it is sealed
+// under the current key, after which the old keys can be dropped.
+func reseal(argv []string) error {
+	fs := flag.NewFlagSet("reseal", flag.ExitOnError)
+	data := addDataFlags(fs)
+	_ = fs.Parse(argv)
+
+	keys, err := store.LoadMasterKeys(*data.keyfile)
+
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return errors.New("data is not encrypted at rest")
+	}
+
+	sealer, err := store.NewSealer(keys)
+
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockDir(*data.dir, false)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// Journal records are sealed too, and only the server can apply them.
+	if err = checkJournal(*data.dir); err != nil {
+		return err
+	}
+
+	backend, err := openBackend(*data.backend, *data.dir, false)
+
+	if err != nil {
+		return err
+	}
+
+	defer backend.Close()
+
+	// Sealed lists are kept as blobs.
+	names := append(append([]string(nil), server.BlobStores...),
+		server.ListStores...)
+
+	for _, name := range names {
+		hidden, err := backend.Keys(name, false)
+
+		if err != nil {
+			return err
+		}
+
+		// Stores are opened only if they have keys, since opening may
+		// create them.
+		if len(hidden) == 0 {
+			continue
+		}
+
+		raw, err := backend.Blobs(name)
+
+		if err != nil {
+			return err
+		}
+
+		n, err := sealer.Reseal(raw, hidden)
+
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		fmt.Printf("%s: %d of %d values resealed\n", name, n, len(hidden))
+	}
+
+	if len(keys) > 1 {
+		fmt.Println("all data is sealed under the current key, the old" +
+			" keys can be dropped")
+	}
+
+	return nil
+}
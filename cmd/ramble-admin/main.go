@@ -0,0 +1,135 @@
+// Command ramble-admin works on the data directory of a ramble server. The
+// convos, inspect and list commands only read the data, while the others need
+// the server to be stopped.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/pkg/server"
+)
+
+const usage = `usage: ramble-admin command [flags]
+
+commands:
+	compact	reclaim space left by removed data
+	convos	show the number of conversations of each user
+	inspect	show the UUIDs and sizes of a conversation's messages
+	list	list the fingerprints of registered keys
+	migrate	copy data to another storage backend
+	purge	remove a user's key and list of conversations
+	reseal	rewrite data encrypted at rest under the current master key
+
+Run "ramble-admin command -h" for the flags of a command.
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	args := os.Args[2:]
+
+	switch os.Args[1] {
+	case "compact":
+		err = compact(args)
+	case "convos":
+		err = convos(args)
+	case "inspect":
+		err = inspect(args)
+	case "list":
+		err = list(args)
+	case "migrate":
+		err = migrate(args)
+	case "purge":
+		err = purge(args)
+	case "reseal":
+		err = reseal(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Opens the storage backend of a data directory. Only backends which are
+// written to are created.
+func openBackend(backend, dir string, create bool) (store.Backend, error) {
+	switch backend {
+	case server.BackendBolt:
+		path := filepath.Join(dir, server.BoltFile)
+
+		if !create {
+			if _, err := os.Stat(path); err != nil {
+				return nil, err
+			}
+		}
+
+		return store.OpenBolt(path)
+	case server.BackendFiles:
+		if !create {
+			if _, err := os.Stat(dir); err != nil {
+				return nil, err
+			}
+		}
+
+		return store.NewFiles(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// Opens the backend of an existing data directory without changing it.
+func openReadOnly(backend, dir string) (store.Backend, error) {
+	switch backend {
+	case server.BackendBolt:
+		return store.OpenBoltReadOnly(filepath.Join(dir, server.BoltFile))
+	case server.BackendFiles:
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+
+		return store.NewReadOnlyFiles(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// Locks a data directory which must exist, so a server cannot use it at the
+// same time.
+func lockDir(dir string, shared bool) (func() error, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	return store.LockDir(dir, shared)
+}
+
+// Checks that the server left no transactions to apply in a data directory.
+func checkJournal(dir string) error {
+	pending, err := store.NewFileLog(filepath.Join(dir,
+		server.JournalDir)).IDs()
+
+	if err != nil {
+		return err
+	}
+
+	if len(pending) != 0 {
+		return errors.New("data has unapplied transactions, start and stop" +
+			" the server to apply them")
+	}
+
+	return nil
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/pkg/server"
+)
+
+// Name of the file in the destination directory recording the progress of a
+// migration, removed once it completes.
+const migrateStateFile = "ramble-migrate.json"
+
+// Number of keys copied between saves of the migration state.
+const checkpointKeys = 100
+
+type migrateState struct {
+	// Done are the stores which are copied and verified.
+	Done []string `json:"done"`
+
+	From string `json:"from"`
+
+	// Key is the last key copied of Store. Keys are copied in ascending
+	// order, and copying a key again is harmless.
+	Key   string `json:"key"`
+	Store string `json:"store"`
+
+	To string `json:"to"`
+}
+
+type migration struct {
+	from   store.Backend
+	to     store.Backend
+	dryRun bool
+	sealed bool
+
+	state     migrateState
+	statePath string
+}
+
+// Count of keys and values of a store, and a SHA-256 hash of them in key order.
+type digest struct {
+	keys   int
+	values int
+	hash   hash.Hash
+}
+
+func newDigest() *digest {
+	return &digest{
+		hash: sha256.New(),
+	}
+}
+
+func (d *digest) add(key string, values ...[]byte) {
+	d.keys++
+	d.values += len(values)
+	d.write([]byte(key))
+
+	for _, v := range values {
+		d.write(v)
+	}
+}
+
+// Writes b prefixed by its length, so that entries cannot run together.
+func (d *digest) write(b []byte) {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(b)))
+	_, _ = d.hash.Write(n[:])
+	_, _ = d.hash.Write(b)
+}
+
+func (d *digest) equal(other *digest) bool {
+	return d.keys == other.keys && d.values == other.values &&
+		bytes.Equal(d.hash.Sum(nil), other.hash.Sum(nil))
+}
+
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("dir", ".", "data directory to read")
+	dryRun := fs.Bool("dry-run", false, "count and checksum the data"+
+		" without copying it")
+	from := fs.String("from", server.BackendFiles, "storage backend to"+
+		" read")
+	out := fs.String("out", "", "data directory to write (default -dir)")
+	resume := fs.Bool("resume", false, "resume an interrupted migration")
+	sealed := fs.Bool("sealed", false, "data is encrypted at rest, so"+
+		" lists are kept as blobs")
+	to := fs.String("to", server.BackendBolt, "storage backend to write")
+	_ = fs.Parse(args)
+
+	if *out == "" {
+		*out = *dir
+	}
+
+	if *from == *to && sameDir(*dir, *out) {
+		return errors.New("source and destination are the same")
+	}
+
+	m := &migration{
+		dryRun:    *dryRun,
+		sealed:    *sealed,
+		statePath: filepath.Join(*out, migrateStateFile),
+	}
+
+	b, err := ioutil.ReadFile(m.statePath)
+
+	switch {
+	case m.dryRun:
+		break
+	case err == nil && !*resume:
+		return errors.New("a migration is in progress, resume it with" +
+			" -resume")
+	case err == nil:
+		if err = json.Unmarshal(b, &m.state); err != nil {
+			return err
+		}
+
+		if m.state.From != *from || m.state.To != *to {
+			return fmt.Errorf("migration in progress is from %s to %s",
+				m.state.From, m.state.To)
+		}
+	case os.IsNotExist(err) && *resume:
+		return errors.New("no migration to resume")
+	case os.IsNotExist(err):
+		m.state = migrateState{
+			From: *from,
+			To:   *to,
+		}
+	default:
+		return err
+	}
+
+	// The source is only read in a dry run, so a read-only server may
+	// share it.
+	unlock, err := lockDir(*dir, m.dryRun)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// Transactions left by a crash are not copied, as only the server can
+	// apply them.
+	if err = checkJournal(*dir); err != nil {
+		return err
+	}
+
+	if m.dryRun {
+		m.from, err = openReadOnly(*from, *dir)
+	} else {
+		m.from, err = openBackend(*from, *dir, false)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer m.from.Close()
+
+	if !m.dryRun {
+		if err = os.MkdirAll(*out, 0700); err != nil {
+			return err
+		}
+
+		if !sameDir(*dir, *out) {
+			unlockOut, err := lockDir(*out, false)
+
+			if err != nil {
+				return err
+			}
+
+			defer unlockOut()
+		}
+
+		if m.to, err = openBackend(*to, *out, true); err != nil {
+			return err
+		}
+
+		defer m.to.Close()
+	}
+
+	for _, name := range server.BlobStores {
+		if err = m.store(name, false); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range server.ListStores {
+		if err = m.store(name, !m.sealed); err != nil {
+			return err
+		}
+	}
+
+	if m.dryRun {
+		return nil
+	}
+
+	if err = os.Remove(m.statePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	fmt.Printf("migrated to %s in %s\n", *to, *out)
+
+	return nil
+}
+
+func sameDir(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && a == b
+}
+
+// Copies a store unless it is done, then checks the copy matches.
+func (m *migration) store(name string, lists bool) error {
+	keys, err := m.from.Keys(name, lists)
+
+	if err != nil {
+		return err
+	}
+
+	var src, dst *storeHandle
+
+	// Stores are opened only if they have keys, since opening may create
+	// them.
+	if len(keys) != 0 {
+		if src, err = openStore(m.from, name, lists); err != nil {
+			return err
+		}
+	}
+
+	done := m.dryRun || contains(m.state.Done, name)
+	resumed := m.state.Store == name
+
+	if !done {
+		if dst, err = openStore(m.to, name, lists); err != nil {
+			return err
+		}
+	}
+
+	if !done && !resumed {
+		existing, err := m.to.Keys(name, lists)
+
+		if err != nil {
+			return err
+		}
+
+		if len(existing) != 0 {
+			return fmt.Errorf("%s: destination is not empty", name)
+		}
+
+		m.state.Store = name
+		m.state.Key = ""
+
+		if err = m.save(); err != nil {
+			return err
+		}
+	}
+
+	sum := newDigest()
+	copied := 0
+
+	for _, key := range keys {
+		values, err := src.read(key)
+
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", name, key, err)
+		}
+
+		sum.add(key, values...)
+
+		if done || (resumed && key <= m.state.Key) {
+			continue
+		}
+
+		if err = dst.write(key, values); err != nil {
+			return fmt.Errorf("%s: %s: %v", name, key, err)
+		}
+
+		if copied++; copied%checkpointKeys == 0 {
+			m.state.Key = key
+
+			if err = m.save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.dryRun {
+		fmt.Printf("%s: %d keys, %d values, sha256 %x\n", name,
+			sum.keys, sum.values, sum.hash.Sum(nil))
+		return nil
+	}
+
+	copySum, err := storeDigest(m.to, name, lists)
+
+	if err != nil {
+		return err
+	}
+
+	if !sum.equal(copySum) {
+		return fmt.Errorf("%s: copy does not match, %d keys and %d"+
+			" values copied of %d and %d", name, copySum.keys,
+			copySum.values, sum.keys, sum.values)
+	}
+
+	fmt.Printf("%s: %d keys, %d values, sha256 %x verified\n", name,
+		sum.keys, sum.values, sum.hash.Sum(nil))
+
+	if !done {
+		m.state.Done = append(m.state.Done, name)
+		m.state.Key = ""
+		m.state.Store = ""
+
+		return m.save()
+	}
+
+	return nil
+}
+
+// Saves the migration state, replacing the previous state whole.
+func (m *migration) save() error {
+	b, err := json.Marshal(m.state)
+
+	if err != nil {
+		return err
+	}
+
+	tmp := m.statePath + ".tmp"
+
+	if err = ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, m.statePath)
+}
+
+func storeDigest(backend store.Backend, name string, lists bool) (*digest, error) {
+	h, err := openStore(backend, name, lists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := backend.Keys(name, lists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDigest()
+
+	for _, key := range keys {
+		values, err := h.read(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", name, key, err)
+		}
+
+		d.add(key, values...)
+	}
+
+	return d, nil
+}
+
+// A store of blobs or lists.
+type storeHandle struct {
+	blobs store.Blobs
+	lists store.Lists
+}
+
+func openStore(backend store.Backend, name string, lists bool) (*storeHandle, error) {
+	var h storeHandle
+	var err error
+
+	if lists {
+		h.lists, err = backend.Lists(name, server.ListValueLen)
+	} else {
+		h.blobs, err = backend.Blobs(name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+// Reads the value of a blob, or the values of a list.
+func (h *storeHandle) read(key string) ([][]byte, error) {
+	if h.lists == nil {
+		v, err := h.blobs.Read(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return [][]byte{v}, nil
+	}
+
+	list, err := h.lists.IndexN(key, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(list))
+
+	for i, v := range list {
+		values[i] = []byte(v)
+	}
+
+	return values, nil
+}
+
+// Writes the value of a blob, or replaces a list with values.
+func (h *storeHandle) write(key string, values [][]byte) error {
+	if h.lists == nil {
+		return h.blobs.Write(key, values[0])
+	}
+
+	err := h.lists.Remove(key)
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, v := range values {
+		if err = h.lists.Insert(key, string(v)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -10,11 +10,19 @@ const (
 // DeleteHelloReq is sent by the client as the initial request to delete stored
 // data.
 type DeleteHelloReq struct {
+	// Mailbox address of a blinded mailbox to delete, in place of "sender".
+	// The type must be DeleteConversations and the verify request signature
+	// is made by MailboxSign.
+	Mailbox string `json:"mailbox,omitempty"`
+
 	// Sender's public key fingerprint.
 	Sender string `json:"sender"`
 
 	// Type of data to delete, representing an enumerated type.
 	Type uint8 `json:"type"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
 }
 
 // DeleteHelloResp is sent by the server in response to DeleteHelloReq.
@@ -0,0 +1,20 @@
+module github.com/esote/ramble
+
+go 1.25.0
+
+require (
+	github.com/ProtonMail/go-crypto v1.3.0
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/net v0.57.0
+	google.golang.org/grpc v1.75.0
+	google.golang.org/protobuf v1.36.9
+)
+
+require (
+	github.com/cloudflare/circl v1.6.1 // indirect
+	golang.org/x/crypto v0.54.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+)
+
@@ -0,0 +1,50 @@
+package ramble
+
+// Version of the protocol described by this package. Requests may carry it so
+// servers speaking another version reject them with a clear error.
+const Version uint32 = 1
+
+// InfoResp is sent by the server describing the protocol it speaks and the
+// limits it enforces.
+type InfoResp struct {
+	// Algorithms are the public key algorithms accepted by the server.
+	Algorithms []string `json:"algorithms"`
+
+	// Limits enforced by the server.
+	Limits Limits `json:"limits"`
+
+	// Requests are the request types supported by the server, such as
+	// "send" or "view".
+	Requests []string `json:"requests"`
+
+	// Version of the protocol spoken by the server.
+	Version uint32 `json:"version"`
+}
+
+// Limits are the limits enforced by a server. Zero values are unlimited.
+type Limits struct {
+	// ChunkSize is the size of attachment chunks in bytes.
+	ChunkSize int `json:"chunk_size"`
+
+	// HandshakeTTL is the number of seconds a hello-verify handshake, and
+	// the signature of its nonce, remain valid.
+	HandshakeTTL int64 `json:"handshake_ttl"`
+
+	// MaxAttachmentSize is the largest attachment accepted by upload
+	// requests, in bytes.
+	MaxAttachmentSize int64 `json:"max_attachment_size"`
+
+	// MaxBatch is the largest number of items accepted by batch send
+	// requests.
+	MaxBatch int `json:"max_batch"`
+
+	// MaxCount is the largest count accepted by view requests.
+	MaxCount uint64 `json:"max_count"`
+
+	// MaxMessageSize is the largest armored message accepted by send
+	// requests, in bytes.
+	MaxMessageSize int `json:"max_msg_size"`
+
+	// SessionTTL is the number of seconds a session stays open.
+	SessionTTL int64 `json:"session_ttl"`
+}
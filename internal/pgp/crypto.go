@@ -0,0 +1,184 @@
+package pgp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// KeyID identifies a key which messages may be encrypted to, in a form chosen
+// by the Crypto backend. The empty KeyID stands for a hidden recipient.
+type KeyID string
+
+// Key is a public key parsed by a Crypto backend. Parsed keys may be cached and
+// reused by their owner's fingerprint.
+type Key interface {
+	// EncryptWriter returns a writer which encrypts plaintext for the owner
+	// of the key, writing the message to w. The message is complete once
+	// the writer is closed.
+	EncryptWriter(w io.Writer) (io.WriteCloser, error)
+
+	// KeyIDs gets the IDs of each key messages may be encrypted to.
+	KeyIDs() []KeyID
+
+	// VerifySig verifies a detached signature of file. Returns the
+	// signature creation time.
+	VerifySig(sig, file io.Reader) (time.Time, error)
+
+	// VerifyUsable checks that the key may be used at time t. Returns
+	// ErrKeyRevoked or ErrKeyExpired for revoked or expired keys.
+	VerifyUsable(t time.Time) error
+}
+
+// Crypto is a cryptographic backend used to identify users by their public
+// keys, verify their signatures, and encrypt data to them. Keys, signatures
+// and messages are passed in the backend's encoded form.
+type Crypto interface {
+	// Algorithms gets the names of the public key algorithms keys may use.
+	Algorithms() []string
+
+	// Fingerprint gets the fingerprint identifying a public key, in the form
+	// accepted by VerifyFingerprint.
+	Fingerprint(public io.Reader) (string, error)
+
+	// Nonce generates a random nonce encoded as hex, to be signed by the
+	// client.
+	Nonce() ([]byte, error)
+
+	// ParseKey parses a public key.
+	ParseKey(public io.Reader) (Key, error)
+
+	// Recipients gets the ID of the key each recipient of an encrypted
+	// message is encrypted to, or the empty KeyID where the recipient is
+	// hidden. Messages which are not well-formed are rejected.
+	Recipients(input io.Reader) ([]KeyID, error)
+
+	// VerifyEncrypted checks that input is a well-formed encrypted message
+	// whose encrypted data is integrity protected.
+	VerifyEncrypted(input io.Reader) (bool, error)
+
+	// VerifyFingerprint does rough checks to see if a fingerprint is valid.
+	VerifyFingerprint(fingerprint string) bool
+
+	// VerifyPublic checks that input is a public key.
+	VerifyPublic(input io.Reader) (bool, error)
+}
+
+// OpenPGP is the default Crypto backend, using armored OpenPGP keys,
+// signatures and messages. Fingerprints are hex and key IDs are the 64-bit
+// OpenPGP key IDs in hex.
+type OpenPGP struct {
+	// Buckets to pad encrypted messages to, or none for no padding.
+	Buckets []int
+}
+
+// Key parsed by OpenPGP.
+type openPGPKey struct {
+	buckets []int
+	key     openpgp.EntityList
+}
+
+// Gets the KeyID of an OpenPGP key ID, where 0 is a hidden recipient.
+func keyID(id uint64) KeyID {
+	if id == 0 {
+		return ""
+	}
+
+	return KeyID(fmt.Sprintf("%016x", id))
+}
+
+// Algorithms returns PublicKeyAlgorithms.
+func (OpenPGP) Algorithms() []string {
+	return append([]string(nil), PublicKeyAlgorithms...)
+}
+
+// Fingerprint calls FingerprintArmored.
+func (OpenPGP) Fingerprint(public io.Reader) (string, error) {
+	f, err := FingerprintArmored(public)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(f), nil
+}
+
+// Nonce calls NonceHex.
+func (OpenPGP) Nonce() ([]byte, error) {
+	return NonceHex()
+}
+
+// ParseKey calls ReadArmoredKey.
+func (o OpenPGP) ParseKey(public io.Reader) (Key, error) {
+	key, err := ReadArmoredKey(public)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &openPGPKey{
+		buckets: o.Buckets,
+		key:     key,
+	}, nil
+}
+
+// Recipients calls RecipientsArmored.
+func (OpenPGP) Recipients(input io.Reader) ([]KeyID, error) {
+	ids, err := RecipientsArmored(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]KeyID, len(ids))
+
+	for i, id := range ids {
+		recipients[i] = keyID(id)
+	}
+
+	return recipients, nil
+}
+
+// VerifyEncrypted calls VerifyEncryptedArmored.
+func (OpenPGP) VerifyEncrypted(input io.Reader) (bool, error) {
+	return VerifyEncryptedArmored(input)
+}
+
+// VerifyFingerprint calls VerifyHexFingerprint.
+func (OpenPGP) VerifyFingerprint(fingerprint string) bool {
+	return VerifyHexFingerprint(fingerprint)
+}
+
+// VerifyPublic calls VerifyPublicArmored.
+func (OpenPGP) VerifyPublic(input io.Reader) (bool, error) {
+	return VerifyPublicArmored(input)
+}
+
+// EncryptWriter calls NewEncryptWriter.
+func (k *openPGPKey) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	return NewEncryptWriter(w, k.key, k.buckets)
+}
+
+// KeyIDs calls KeyIDs.
+func (k *openPGPKey) KeyIDs() []KeyID {
+	var ids []KeyID
+
+	for _, id := range KeyIDs(k.key) {
+		ids = append(ids, keyID(id))
+	}
+
+	return ids
+}
+
+// VerifySig calls VerifySig.
+func (k *openPGPKey) VerifySig(sig, file io.Reader) (time.Time, error) {
+	return VerifySig(k.key, sig, file)
+}
+
+// VerifyUsable calls VerifyUsable.
+func (k *openPGPKey) VerifyUsable(t time.Time) error {
+	return VerifyUsable(k.key, t)
+}
@@ -11,87 +11,291 @@ import (
 	"regexp"
 	"time"
 
-	"golang.org/x/crypto/openpgp"
-	"golang.org/x/crypto/openpgp/armor"
-	"golang.org/x/crypto/openpgp/packet"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+var (
+	// ErrKeyExpired is returned when a key has passed its expiry time.
+	ErrKeyExpired = pgperrors.ErrKeyExpired
+
+	// ErrKeyRevoked is returned when a key carries a valid revocation
+	// signature.
+	ErrKeyRevoked = pgperrors.ErrKeyRevoked
+
+	// ErrMultipleKeys is returned when an armored key ring holds more than
+	// one key. Each user is identified by exactly one primary key.
+	ErrMultipleKeys = errors.New("key ring contains more than one key")
+
+	// ErrNoIntegrity is returned when a message's encrypted data is not
+	// integrity protected.
+	ErrNoIntegrity = errors.New("encrypted data is not integrity protected")
 )
 
 const (
-	encType           = "PGP MESSAGE"
-	fingerprintHexLen = 2 * 20
-	nonceLen          = 1024
+	encType = "PGP MESSAGE"
+
+	// v4 keys have SHA-1 fingerprints, v6 keys have SHA-256 fingerprints.
+	fingerprintHexLen   = 2 * 20
+	fingerprintV6HexLen = 2 * 32
+
+	nonceLen = 1024
 
 	// NonceHexLen is the length of a hexadecimal nonce in bytes.
 	NonceHexLen = 2 * nonceLen
 )
 
+// DefaultBuckets are padding bucket sizes suitable for short messages.
+var DefaultBuckets = []int{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10,
+	1 << 20}
+
+// PublicKeyAlgorithms are the names of the public key algorithms keys may use,
+// as supported by the OpenPGP library.
+var PublicKeyAlgorithms = []string{"dsa", "ecdh", "ecdsa", "ed25519", "ed448",
+	"eddsa", "elgamal", "rsa", "x25519", "x448"}
+
 // EncryptArmored encrypts plaintext for one recipient by proving a plaintext
 // and an armored public key. Returns an armored, encrypted PGP message.
 //
-// The recipient is hidden using a speculative key ID.
+// The recipient is hidden using a speculative key ID. AEAD encryption is used
+// when the recipient's key advertises support for it.
 func EncryptArmored(public, plain io.Reader) ([]byte, error) {
-	key, err := openpgp.ReadArmoredKeyRing(public)
+	return EncryptArmoredPadded(public, plain, nil)
+}
+
+// EncryptArmoredPadded is EncryptArmored with a padding packet added inside the
+// encryption, so the encrypted packets fill the smallest bucket size they fit
+// in. Larger messages are padded to a multiple of the largest bucket. Buckets
+// must be increasing, and no padding is added if there are none.
+func EncryptArmoredPadded(public, plain io.Reader, buckets []int) ([]byte, error) {
+	var armored bytes.Buffer
+
+	wc, err := NewArmoredEncryptWriter(&armored, public, buckets)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Use speculative key IDs to countermeasure traffic analysis.
-	for _, entity := range key {
-		entity.PrimaryKey.KeyId = 0
+	if _, err = io.Copy(wc, plain); err != nil {
+		return nil, err
+	}
 
-		for _, subkey := range entity.Subkeys {
-			subkey.PublicKey.KeyId = 0
-		}
+	if err = wc.Close(); err != nil {
+		return nil, err
 	}
 
+	return armored.Bytes(), nil
+}
+
+// NewArmoredEncryptWriter is the streaming form of EncryptArmoredPadded.
+// Plaintext written to the returned writer is encrypted as it is written, and
+// the armored message is written to w. The message is complete once the writer
+// is closed, which does not close w.
+func NewArmoredEncryptWriter(w io.Writer, public io.Reader, buckets []int) (io.WriteCloser, error) {
+	key, err := ReadArmoredKey(public)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEncryptWriter(w, key, buckets)
+}
+
+// NewEncryptWriter is NewArmoredEncryptWriter with a key read by
+// ReadArmoredKey.
+func NewEncryptWriter(w io.Writer, key openpgp.EntityList, buckets []int) (io.WriteCloser, error) {
 	config := &packet.Config{
+		AEADConfig: &packet.AEADConfig{
+			DefaultMode: packet.AEADModeOCB,
+		},
 		DefaultCipher: packet.CipherAES256,
 		DefaultHash:   crypto.SHA512,
 	}
 
-	hints := &openpgp.FileHints{
-		IsBinary: true,
-	}
+	armored, err := armor.Encode(w, encType, nil)
 
-	var encrypted bytes.Buffer
+	if err != nil {
+		return nil, err
+	}
 
-	wc, err := openpgp.Encrypt(&encrypted, key, nil, hints, config)
+	enc, err := newEncryptWriter(armored, key[0], buckets, config)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err = io.Copy(wc, plain); err != nil {
-		return nil, err
+	return &armoredWriter{
+		WriteCloser: enc,
+		armored:     armored,
+	}, nil
+}
+
+// Closes the armor after the encrypted message.
+type armoredWriter struct {
+	io.WriteCloser
+	armored io.WriteCloser
+}
+
+func (a *armoredWriter) Close() error {
+	if err := a.WriteCloser.Close(); err != nil {
+		return err
 	}
 
-	if err = wc.Close(); err != nil {
-		return nil, err
+	return a.armored.Close()
+}
+
+// Writes plaintext as a binary PGP message encrypted to an entity, padded to
+// buckets once closed.
+type encryptWriter struct {
+	payload io.WriteCloser
+	literal io.WriteCloser
+	counter *countWriter
+	buckets []int
+	config  *packet.Config
+}
+
+// Starts a binary PGP message encrypted to entity. openpgp.Encrypt cannot hide
+// recipients, so the packets are serialized here instead.
+func newEncryptWriter(w io.Writer, entity *openpgp.Entity, buckets []int, config *packet.Config) (*encryptWriter, error) {
+	key, ok := entity.EncryptionKey(config.Now())
+
+	if !ok {
+		return nil, errors.New("key has no usable encryption key")
 	}
 
-	var armored bytes.Buffer
+	sig, _ := entity.PrimarySelfSignature()
+	aead := sig != nil && sig.SEIPDv2
+
+	cipher := config.Cipher()
+	suite := packet.CipherSuite{
+		Cipher: cipher,
+		Mode:   config.AEAD().Mode(),
+	}
+
+	session := make([]byte, cipher.KeySize())
+
+	if _, err := io.ReadFull(config.Random(), session); err != nil {
+		return nil, err
+	}
 
-	wc, err = armor.Encode(&armored, encType, nil)
+	// Use speculative key IDs to countermeasure traffic analysis.
+	err := packet.SerializeEncryptedKeyAEADwithHiddenOption(w,
+		key.PublicKey, cipher, aead, session, true, config)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err = encrypted.WriteTo(wc); err != nil {
+	payload, err := packet.SerializeSymmetricallyEncrypted(w, cipher, aead,
+		suite, session, config)
+
+	if err != nil {
 		return nil, err
 	}
 
-	if err = wc.Close(); err != nil {
+	// The literal data is counted to know how much padding it needs.
+	counter := &countWriter{w: payload}
+	literal, err := packet.SerializeLiteral(nopCloser{counter}, true, "", 0)
+
+	if err != nil {
 		return nil, err
 	}
 
-	return armored.Bytes(), nil
+	return &encryptWriter{
+		payload: payload,
+		literal: literal,
+		counter: counter,
+		buckets: buckets,
+		config:  config,
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	return e.literal.Write(p)
+}
+
+// Close ends the literal data, pads it, and ends the encrypted data.
+func (e *encryptWriter) Close() error {
+	if err := e.literal.Close(); err != nil {
+		return err
+	}
+
+	for _, pad := range padding(e.counter.n, e.buckets) {
+		err := pad.SerializePadding(e.payload, e.config.Random())
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.payload.Close()
 }
 
-// FingerprintArmored gets the fingerprint from an armored public key.
+type countWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+
+	return n, err
+}
+
+// Gets the padding packets needed to pad n bytes of packets to a bucket.
+func padding(n int, buckets []int) []packet.Padding {
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	// The smallest padding packet is its 2 byte header.
+	size := -1
+
+	for _, b := range buckets {
+		if n+2 <= b {
+			size = b
+			break
+		}
+	}
+
+	if size == -1 {
+		largest := buckets[len(buckets)-1]
+		size = (n + 2 + largest - 1) / largest * largest
+	}
+
+	var pads []packet.Padding
+
+	// Padding packet headers grow with the body length, so some sizes
+	// cannot be filled by one packet. Those take an extra empty packet.
+	for r := size - n; ; r -= 2 {
+		switch {
+		case r-2 < 192:
+			return append(pads, packet.Padding(r-2))
+		case r-3 >= 192 && r-3 < 8384:
+			return append(pads, packet.Padding(r-3))
+		case r-6 >= 8384:
+			return append(pads, packet.Padding(r-6))
+		}
+
+		pads = append(pads, 0)
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// FingerprintArmored gets the primary key fingerprint from an armored public
+// key. Key rings holding more than one primary key are rejected.
 func FingerprintArmored(public io.Reader) ([]byte, error) {
-	key, err := openpgp.ReadArmoredKeyRing(public)
+	key, err := ReadArmoredKey(public)
 
 	if err != nil {
 		return nil, err
@@ -100,6 +304,29 @@ func FingerprintArmored(public io.Reader) ([]byte, error) {
 	return key[0].PrimaryKey.Fingerprint[:], nil
 }
 
+// KeyIDsArmored gets the key IDs of the primary key and each subkey of an
+// armored public key.
+func KeyIDsArmored(public io.Reader) ([]uint64, error) {
+	key, err := ReadArmoredKey(public)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return KeyIDs(key), nil
+}
+
+// KeyIDs is KeyIDsArmored with a key read by ReadArmoredKey.
+func KeyIDs(key openpgp.EntityList) []uint64 {
+	ids := []uint64{key[0].PrimaryKey.KeyId}
+
+	for _, sub := range key[0].Subkeys {
+		ids = append(ids, sub.PublicKey.KeyId)
+	}
+
+	return ids
+}
+
 // NonceHex generates a random nonce encoded as hex.
 func NonceHex() (nonce []byte, err error) {
 	b := make([]byte, nonceLen)
@@ -114,57 +341,121 @@ func NonceHex() (nonce []byte, err error) {
 	return
 }
 
-// VerifyArmoredSig uses an armored public key to verify an armored, detached
-// signature. Returns the signature creation time.
-func VerifyArmoredSig(public, sig, file io.Reader) (t time.Time, err error) {
-	k, err := openpgp.ReadArmoredKeyRing(public)
+// RecipientsArmored walks an armored, encrypted PGP message and returns the
+// key ID of each encrypted session key, or 0 where the recipient is hidden.
+//
+// The message must consist of encrypted session keys followed by a single
+// integrity-protected data packet.
+func RecipientsArmored(input io.Reader) ([]uint64, error) {
+	blk, err := armor.Decode(input)
 
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	if blk.Type != encType {
+		return nil, errors.New("incorrect block type")
 	}
 
-	// Read into buffer since we need to read it twice.
-	buffer, err := ioutil.ReadAll(sig)
+	return recipients(blk.Body)
+}
 
-	if err != nil {
-		return
+// Walks the packets of a binary PGP message, see RecipientsArmored.
+func recipients(r io.Reader) ([]uint64, error) {
+	packets := packet.NewReader(r)
+
+	var ids []uint64
+	var data io.Reader
+
+	for data == nil {
+		p, err := packets.Next()
+
+		if err == io.EOF {
+			return nil, errors.New("message has no encrypted data")
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch p := p.(type) {
+		case *packet.EncryptedKey:
+			ids = append(ids, p.KeyId)
+		case *packet.SymmetricallyEncrypted:
+			if !p.IntegrityProtected {
+				return nil, ErrNoIntegrity
+			}
+
+			data = p.Contents
+		case *packet.AEADEncrypted:
+			data = p.Contents
+		default:
+			return nil, errors.New("incorrect packet type")
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("message has no recipients")
 	}
 
-	reader := bytes.NewReader(buffer)
+	// Read past the encrypted data, which also checks it is not truncated.
+	if _, err := io.Copy(ioutil.Discard, data); err != nil {
+		return nil, err
+	}
+
+	switch _, err := packets.Next(); err {
+	case io.EOF:
+		return ids, nil
+	case nil:
+		return nil, errors.New("trailing data after encrypted data")
+	default:
+		return nil, err
+	}
+}
 
-	// Takes care of the actual signature validation.
-	_, err = openpgp.CheckArmoredDetachedSignature(k, file, reader)
+// VerifyArmoredSig uses an armored public key to verify an armored, detached
+// signature. Returns the signature creation time.
+//
+// Signatures made by expired or revoked keys are rejected.
+func VerifyArmoredSig(public, sig, file io.Reader) (time.Time, error) {
+	key, err := ReadArmoredKey(public)
 
 	if err != nil {
-		return
+		return time.Time{}, err
 	}
 
-	reader = bytes.NewReader(buffer)
-	blk, err := armor.Decode(reader)
+	return VerifySig(key, sig, file)
+}
+
+// VerifySig is VerifyArmoredSig with a key read by ReadArmoredKey. The
+// signature is decoded as it is read.
+func VerifySig(key openpgp.EntityList, sig, file io.Reader) (t time.Time, err error) {
+	blk, err := armor.Decode(sig)
 
 	if err != nil {
 		return
 	}
 
-	p, err := packet.NewReader(blk.Body).Next()
+	if blk.Type != openpgp.SignatureType {
+		return t, errors.New("incorrect block type")
+	}
+
+	// Takes care of the actual signature validation, including signing
+	// subkey bindings, expiry and revocation.
+	s, _, err := openpgp.VerifyDetachedSignature(key, file, blk.Body, nil)
 
 	if err != nil {
 		return
 	}
 
 	// Return the signature creation time.
-	switch sig := p.(type) {
-	case *packet.Signature:
-		return sig.CreationTime, nil
-	case *packet.SignatureV3:
-		return sig.CreationTime, nil
-	default:
-		return t, errors.New("incorrect packet type")
-	}
+	return s.CreationTime, nil
 }
 
 // VerifyEncryptedArmored tries to validate that input is indeed an armored,
 // encrypted PGP message.
+//
+// The full packet sequence is parsed. Messages whose encrypted data is not
+// integrity protected, by a modification detection code or AEAD, are rejected
+// with ErrNoIntegrity.
 func VerifyEncryptedArmored(input io.Reader) (bool, error) {
 	blk, err := armor.Decode(input)
 
@@ -176,18 +467,59 @@ func VerifyEncryptedArmored(input io.Reader) (bool, error) {
 		return false, errors.New("incorrect block type")
 	}
 
-	p, err := packet.NewReader(blk.Body).Next()
+	if _, err = recipients(blk.Body); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// VerifyUsableArmored checks that an armored public key has a valid
+// self-signature and is neither revoked nor expired at time t. Returns
+// ErrKeyRevoked or ErrKeyExpired for keys which are otherwise well-formed.
+func VerifyUsableArmored(public io.Reader, t time.Time) error {
+	// Reading the key verifies self-signatures and revocations.
+	key, err := ReadArmoredKey(public)
 
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	switch p.(type) {
-	case *packet.EncryptedKey:
-		return true, nil
-	default:
-		return false, errors.New("incorrect packet type")
+	return VerifyUsable(key, t)
+}
+
+// VerifyUsable is VerifyUsableArmored with a key read by ReadArmoredKey.
+func VerifyUsable(key openpgp.EntityList, t time.Time) error {
+	for _, entity := range key {
+		if err := entityUsable(entity, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks that an entity is neither revoked nor expired at time t.
+func entityUsable(entity *openpgp.Entity, t time.Time) error {
+	if entity.Revoked(t) {
+		return ErrKeyRevoked
+	}
+
+	sig, ident := entity.PrimarySelfSignature()
+
+	if sig == nil {
+		return errors.New("key has no valid self-signature")
+	}
+
+	if ident != nil && ident.Revoked(t) {
+		return ErrKeyRevoked
+	}
+
+	if entity.PrimaryKey.KeyExpired(sig, t) {
+		return ErrKeyExpired
 	}
+
+	return nil
 }
 
 var reHex = regexp.MustCompile("^[a-fA-F0-9]+$")
@@ -195,12 +527,16 @@ var reHex = regexp.MustCompile("^[a-fA-F0-9]+$")
 // VerifyHexFingerprint does rough checks to see if the input fingerprint is
 // valid.
 func VerifyHexFingerprint(fingerprint string) bool {
-	return len(fingerprint) == fingerprintHexLen &&
-		reHex.MatchString(fingerprint)
+	switch len(fingerprint) {
+	case fingerprintHexLen, fingerprintV6HexLen:
+		return reHex.MatchString(fingerprint)
+	default:
+		return false
+	}
 }
 
 // VerifyPublicArmored tries to validate that input is indeed an armored public
-// key.
+// key. The key must be a single primary key, optionally with subkeys.
 func VerifyPublicArmored(input io.Reader) (bool, error) {
 	blk, err := armor.Decode(input)
 
@@ -212,16 +548,52 @@ func VerifyPublicArmored(input io.Reader) (bool, error) {
 		return false, errors.New("incorrect block type")
 	}
 
-	p, err := packet.NewReader(blk.Body).Next()
+	packets := packet.NewReader(blk.Body)
+	p, err := packets.Next()
 
 	if err != nil {
 		return false, err
 	}
 
-	switch p.(type) {
+	switch p := p.(type) {
 	case *packet.PublicKey:
-		return true, nil
+		if p.IsSubkey {
+			return false, errors.New("key begins with a subkey")
+		}
 	default:
 		return false, errors.New("incorrect packet type")
 	}
+
+	// Reading the entity verifies self-signatures and subkey bindings.
+	packets.Unread(p)
+
+	if _, err = openpgp.ReadEntity(packets); err != nil {
+		return false, err
+	}
+
+	// The entity ends at the next primary key, if there is one.
+	switch _, err = packets.Next(); err {
+	case io.EOF:
+		return true, nil
+	case nil:
+		return false, ErrMultipleKeys
+	default:
+		return false, err
+	}
+}
+
+// ReadArmoredKey reads an armored key ring which must hold exactly one primary
+// key, for use with the functions taking a parsed key.
+func ReadArmoredKey(public io.Reader) (openpgp.EntityList, error) {
+	key, err := openpgp.ReadArmoredKeyRing(public)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != 1 {
+		return nil, ErrMultipleKeys
+	}
+
+	return key, nil
 }
@@ -0,0 +1,573 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketBlobs = []byte("blobs")
+	bucketLists = []byte("lists")
+	bucketMeta  = []byte("meta")
+
+	// Buckets of each list, holding values by insertion order and the
+	// order of each value.
+	bucketIndex = []byte("index")
+	bucketOrder = []byte("order")
+
+	keyVersion = []byte("version")
+)
+
+// Migrations of the bbolt schema. The schema version is the number of
+// migrations applied, and new migrations must only be appended.
+var migrations = []func(tx *bolt.Tx) error{
+	// Buckets holding the metadata, blobs and lists.
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketBlobs, bucketLists} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// BoltVersion is the bbolt schema version written by this package.
+var BoltVersion = uint64(len(migrations))
+
+// Bolt keeps blobs and lists in buckets of a bbolt database. Each write is a
+// transaction of its own, unless the store is bound to a transaction by a
+// journal from NewBoltJournal. Lists keep their values in insertion order with
+// an index of the values they hold.
+type Bolt struct {
+	db       *bolt.DB
+	path     string
+	readOnly bool
+}
+
+// Size of the transactions copying data when compacting.
+const compactTxSize = 64 << 20
+
+var boltOptions = &bolt.Options{
+	Timeout: time.Second,
+}
+
+// OpenBolt opens the bbolt database at path, creating it if needed, and
+// migrates it to BoltVersion. A database is only opened by one process at a
+// time, so opening fails if another holds it.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, boltOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bolt{
+		db:   db,
+		path: path,
+	}
+
+	if err = b.migrate(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// OpenBoltReadOnly opens the bbolt database at path without changing it. The
+// database must exist and be at BoltVersion. Stores which do not exist are
+// empty, and writes fail.
+func OpenBoltReadOnly(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		ReadOnly: true,
+		Timeout:  boltOptions.Timeout,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bolt{
+		db:       db,
+		path:     path,
+		readOnly: true,
+	}
+
+	version, err := b.Version()
+
+	if err == nil && version != BoltVersion {
+		err = fmt.Errorf("bolt schema version %d is not %d, start the"+
+			" server to migrate it", version, BoltVersion)
+	}
+
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Applies migrations after the stored schema version in one transaction.
+func (b *Bolt) migrate() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+
+		if err != nil {
+			return err
+		}
+
+		var version uint64
+
+		if v := meta.Get(keyVersion); v != nil {
+			version = binary.BigEndian.Uint64(v)
+		}
+
+		if version > BoltVersion {
+			return fmt.Errorf("bolt schema version %d is newer than"+
+				" %d", version, BoltVersion)
+		}
+
+		for _, m := range migrations[version:] {
+			if err = m(tx); err != nil {
+				return err
+			}
+		}
+
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, BoltVersion)
+
+		return meta.Put(keyVersion, v)
+	})
+}
+
+// Version gets the schema version of the database.
+func (b *Bolt) Version() (version uint64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+
+		if meta == nil {
+			return nil
+		}
+
+		if v := meta.Get(keyVersion); v != nil {
+			version = binary.BigEndian.Uint64(v)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// Close closes the database.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// Compact copies the database to a new file, which then replaces it, since
+// bbolt does not shrink its file as data is removed. Stores opened before
+// compacting use the new file.
+func (b *Bolt) Compact() error {
+	if b.readOnly {
+		return errReadOnly
+	}
+
+	tmp := b.path + ".compact"
+
+	// A previous compaction may have been interrupted.
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	dst, err := bolt.Open(tmp, 0600, boltOptions)
+
+	if err != nil {
+		return err
+	}
+
+	if err = bolt.Compact(dst, b.db, compactTxSize); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err = dst.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err = b.db.Close(); err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp, b.path)
+	db, oerr := bolt.Open(b.path, 0600, boltOptions)
+
+	if oerr != nil {
+		return oerr
+	}
+
+	b.db = db
+
+	return err
+}
+
+// Creates the bucket of a named store within parent. A read-only database is
+// left unchanged, so its missing stores are empty.
+func (b *Bolt) create(parent []byte, name string) error {
+	if b.readOnly {
+		return nil
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.Bucket(parent).CreateBucketIfNotExists([]byte(name))
+		return err
+	})
+}
+
+// Calls fn in bound, or in a read-only transaction if nil.
+func (b *Bolt) view(bound *bolt.Tx, fn func(*bolt.Tx) error) error {
+	if bound != nil {
+		return fn(bound)
+	}
+
+	return b.db.View(fn)
+}
+
+// Calls fn in bound, or in a transaction of its own if nil.
+func (b *Bolt) update(bound *bolt.Tx, fn func(*bolt.Tx) error) error {
+	if bound != nil {
+		return fn(bound)
+	}
+
+	return b.db.Update(fn)
+}
+
+// Keys gets the keys of the named blobs, or of the named lists if lists is
+// set, in ascending order.
+func (b *Bolt) Keys(name string, lists bool) (keys []string, err error) {
+	parent := bucketBlobs
+
+	if lists {
+		parent = bucketLists
+	}
+
+	err = b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(parent).Bucket([]byte(name))
+
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+
+	return
+}
+
+type boltBlobs struct {
+	b    *Bolt
+	name []byte
+
+	// Transaction the blobs are bound to, if any.
+	tx *bolt.Tx
+}
+
+// Blobs gets the named blobs.
+func (b *Bolt) Blobs(name string) (Blobs, error) {
+	if err := b.create(bucketBlobs, name); err != nil {
+		return nil, err
+	}
+
+	return &boltBlobs{
+		b:    b,
+		name: []byte(name),
+	}, nil
+}
+
+func (b *boltBlobs) bindBolt(tx *bolt.Tx) (Blobs, bool) {
+	return &boltBlobs{
+		b:    b.b,
+		name: b.name,
+		tx:   tx,
+	}, true
+}
+
+func (b *boltBlobs) bucket(tx *bolt.Tx) *bolt.Bucket {
+	return tx.Bucket(bucketBlobs).Bucket(b.name)
+}
+
+func (b *boltBlobs) Read(key string) (value []byte, err error) {
+	err = b.b.view(b.tx, func(tx *bolt.Tx) error {
+		bucket := b.bucket(tx)
+
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+
+		v := bucket.Get([]byte(key))
+
+		if v == nil {
+			return os.ErrNotExist
+		}
+
+		value = append([]byte{}, v...)
+		return nil
+	})
+
+	return
+}
+
+func (b *boltBlobs) Write(key string, value []byte) error {
+	return b.b.update(b.tx, func(tx *bolt.Tx) error {
+		return b.bucket(tx).Put([]byte(key), value)
+	})
+}
+
+func (b *boltBlobs) Remove(key string) error {
+	return b.b.update(b.tx, func(tx *bolt.Tx) error {
+		bucket := b.bucket(tx)
+
+		if bucket.Get([]byte(key)) == nil {
+			return os.ErrNotExist
+		}
+
+		return bucket.Delete([]byte(key))
+	})
+}
+
+type boltLists struct {
+	b    *Bolt
+	name []byte
+	vlen int
+
+	// Transaction the lists are bound to, if any.
+	tx *bolt.Tx
+}
+
+// Lists gets the named lists of values vlen bytes long.
+func (b *Bolt) Lists(name string, vlen int) (Lists, error) {
+	if err := b.create(bucketLists, name); err != nil {
+		return nil, err
+	}
+
+	return &boltLists{
+		b:    b,
+		name: []byte(name),
+		vlen: vlen,
+	}, nil
+}
+
+func (l *boltLists) bindBolt(tx *bolt.Tx) (Lists, bool) {
+	return &boltLists{
+		b:    l.b,
+		name: l.name,
+		vlen: l.vlen,
+		tx:   tx,
+	}, true
+}
+
+func (l *boltLists) bucket(tx *bolt.Tx) *bolt.Bucket {
+	return tx.Bucket(bucketLists).Bucket(l.name)
+}
+
+func (l *boltLists) IndexN(key string, n uint64) (values []string, err error) {
+	err = l.b.view(l.tx, func(tx *bolt.Tx) error {
+		bucket := l.bucket(tx)
+
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+
+		list := bucket.Bucket([]byte(key))
+
+		if list == nil {
+			return os.ErrNotExist
+		}
+
+		c := list.Bucket(bucketOrder).Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if n != 0 && uint64(len(values)) == n {
+				break
+			}
+
+			values = append(values, string(v))
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (l *boltLists) Insert(key, value string) error {
+	return l.insert(key, value, false)
+}
+
+func (l *boltLists) InsertUnique(key, value string) error {
+	return l.insert(key, value, true)
+}
+
+func (l *boltLists) insert(key, value string, unique bool) error {
+	if len(value) != l.vlen {
+		return errors.New("value length invalid")
+	}
+
+	return l.b.update(l.tx, func(tx *bolt.Tx) error {
+		list, err := l.bucket(tx).CreateBucketIfNotExists([]byte(key))
+
+		if err != nil {
+			return err
+		}
+
+		index, err := list.CreateBucketIfNotExists(bucketIndex)
+
+		if err != nil {
+			return err
+		}
+
+		order, err := list.CreateBucketIfNotExists(bucketOrder)
+
+		if err != nil {
+			return err
+		}
+
+		if unique && index.Get([]byte(value)) != nil {
+			return nil
+		}
+
+		seq, err := order.NextSequence()
+
+		if err != nil {
+			return err
+		}
+
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, seq)
+
+		if err = order.Put(k, []byte(value)); err != nil {
+			return err
+		}
+
+		return index.Put([]byte(value), k)
+	})
+}
+
+func (l *boltLists) Remove(key string) error {
+	return l.b.update(l.tx, func(tx *bolt.Tx) error {
+		err := l.bucket(tx).DeleteBucket([]byte(key))
+
+		if err == bolt.ErrBucketNotFound {
+			return os.ErrNotExist
+		}
+
+		return err
+	})
+}
+
+// Blobs which can join a transaction of their bbolt database.
+type boltBlobsBinder interface {
+	bindBolt(tx *bolt.Tx) (Blobs, bool)
+}
+
+// Lists which can join a transaction of their bbolt database.
+type boltListsBinder interface {
+	bindBolt(tx *bolt.Tx) (Lists, bool)
+}
+
+// Binds blobs to tx, if they are kept in its database.
+func bindBlobs(blobs Blobs, tx *bolt.Tx) (Blobs, bool) {
+	if b, ok := blobs.(boltBlobsBinder); ok {
+		return b.bindBolt(tx)
+	}
+
+	return nil, false
+}
+
+// Binds lists to tx, if they are kept in its database.
+func bindLists(lists Lists, tx *bolt.Tx) (Lists, bool) {
+	if l, ok := lists.(boltListsBinder); ok {
+		return l.bindBolt(tx)
+	}
+
+	return nil, false
+}
+
+func (b *sealedBlobs) bindBolt(tx *bolt.Tx) (Blobs, bool) {
+	blobs, ok := bindBlobs(b.blobs, tx)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &sealedBlobs{
+		sealer: b.sealer,
+		blobs:  blobs,
+	}, true
+}
+
+func (l *lists) bindBolt(tx *bolt.Tx) (Lists, bool) {
+	blobs, ok := bindBlobs(l.blobs, tx)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &lists{
+		blobs: blobs,
+		vlen:  l.vlen,
+	}, true
+}
+
+// NewBoltJournal creates a journal which applies each transaction in one
+// transaction of b, so no log is kept. Stores registered with it must be kept
+// in b.
+func NewBoltJournal(b *Bolt) *Journal {
+	j := NewJournal(nil)
+	j.bolt = b
+
+	return j
+}
+
+// Applies ops in one transaction of the journal's database.
+func (j *Journal) commitBolt(ops []op) error {
+	return j.bolt.db.Update(func(tx *bolt.Tx) error {
+		bound := NewJournal(nil)
+
+		for name, b := range j.blobs {
+			blobs, ok := bindBlobs(b, tx)
+
+			if !ok {
+				return errors.New("journal blobs " + name + " not" +
+					" kept in its database")
+			}
+
+			bound.blobs[name] = blobs
+		}
+
+		for name, l := range j.lists {
+			lists, ok := bindLists(l, tx)
+
+			if !ok {
+				return errors.New("journal lists " + name + " not" +
+					" kept in its database")
+			}
+
+			bound.lists[name] = lists
+		}
+
+		return bound.apply(ops)
+	})
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Depth of the directories splayed files are kept in.
+const splayDepth = 2
+
+// Backend opens the named stores of a data directory.
+type Backend interface {
+	// Blobs gets the named blobs.
+	Blobs(name string) (Blobs, error)
+
+	// Lists gets the named lists of values vlen bytes long.
+	Lists(name string, vlen int) (Lists, error)
+
+	// Keys gets the keys of the named blobs, or of the named lists if
+	// lists is set, in ascending order.
+	Keys(name string, lists bool) ([]string, error)
+
+	// Close closes the backend.
+	Close() error
+
+	// Compact reclaims space left by removed data. It must not be called
+	// while the backend is otherwise in use.
+	Compact() error
+}
+
+type files struct {
+	dir      string
+	readOnly bool
+}
+
+// NewFiles keeps each store as a directory of splayed files in dir. Lists are
+// kept as the files of NewLists.
+func NewFiles(dir string) Backend {
+	return files{
+		dir: dir,
+	}
+}
+
+// NewReadOnlyFiles opens the stores of NewFiles without changing them. Stores
+// which do not exist are empty, and writes fail.
+func NewReadOnlyFiles(dir string) Backend {
+	return files{
+		dir:      dir,
+		readOnly: true,
+	}
+}
+
+func (f files) Blobs(name string) (Blobs, error) {
+	if f.readOnly {
+		if ok, err := f.exists(name); err != nil || !ok {
+			return emptyStore{}, err
+		}
+	}
+
+	s, err := newSplay(filepath.Join(f.dir, name), splayDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if f.readOnly {
+		return readOnlyBlobs{s}, nil
+	}
+
+	return s, nil
+}
+
+func (f files) Lists(name string, vlen int) (Lists, error) {
+	if f.readOnly {
+		if ok, err := f.exists(name); err != nil || !ok {
+			return emptyStore{}, err
+		}
+	}
+
+	s, err := newSplay(filepath.Join(f.dir, name), splayDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if f.readOnly {
+		return readOnlyLists{NewLists(s, vlen)}, nil
+	}
+
+	return NewLists(s, vlen), nil
+}
+
+// Checks whether the directory of a store exists, since opening it creates it.
+func (f files) exists(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(f.dir, name))
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Keys are the names of the files of a store, which are kept under their key at
+// any depth. Files still being written are skipped.
+func (f files) Keys(name string, _ bool) ([]string, error) {
+	var keys []string
+
+	err := filepath.Walk(filepath.Join(f.dir, name),
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.Mode().IsRegular() &&
+				!strings.HasPrefix(info.Name(), tempPrefix) {
+				keys = append(keys, info.Name())
+			}
+
+			return nil
+		})
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (files) Close() error {
+	return nil
+}
+
+// Compact removes empty directories left within stores by removed files.
+func (f files) Compact() error {
+	if f.readOnly {
+		return errReadOnly
+	}
+
+	stores, err := ioutil.ReadDir(f.dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, store := range stores {
+		if !store.IsDir() {
+			continue
+		}
+
+		var dirs []string
+		root := filepath.Join(f.dir, store.Name())
+
+		err = filepath.Walk(root, func(path string, info os.FileInfo,
+			err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() && path != root {
+				dirs = append(dirs, path)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		// Walk visits parents before children, so children are
+		// removed first by going backwards.
+		for i := len(dirs) - 1; i >= 0; i-- {
+			entries, err := ioutil.ReadDir(dirs[i])
+
+			if err != nil {
+				return err
+			}
+
+			if len(entries) != 0 {
+				continue
+			}
+
+			if err = os.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,616 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of times the writes of a transaction are attempted before it is rolled
+// back.
+const applyAttempts = 3
+
+const (
+	opInsert     = "insert"
+	opRemove     = "remove"
+	opRemoveList = "remove_list"
+	opWrite      = "write"
+)
+
+// Log durably records the transactions of a journal until they are applied.
+type Log interface {
+	// Append records a transaction, which is committed once Append returns
+	// nil.
+	Append(id string, record []byte) error
+
+	// IDs gets the IDs of recorded transactions in ascending order.
+	IDs() ([]string, error)
+
+	// Read gets a recorded transaction.
+	Read(id string) ([]byte, error)
+
+	// Remove removes a recorded transaction.
+	Remove(id string) error
+}
+
+// Suffix of log records being written.
+const tmpSuffix = ".tmp"
+
+// Suffix of the log record marking a rolled back transaction whose record could
+// not be removed, so that Recover discards it.
+const rollbackSuffix = ".rollback"
+
+type fileLog struct {
+	dir string
+}
+
+// NewFileLog keeps log records as files in dir, which is created once needed.
+// Records are written to a temporary file which is synced and then renamed into
+// place, so a record is either whole or missing after a crash.
+func NewFileLog(dir string) Log {
+	return fileLog{
+		dir: dir,
+	}
+}
+
+func (l fileLog) Append(id string, record []byte) error {
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(l.dir, id)
+	tmp := path + tmpSuffix
+
+	if err := writeSync(tmp, record); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return syncDir(l.dir)
+}
+
+func (l fileLog) IDs() ([]string, error) {
+	files, err := ioutil.ReadDir(l.dir)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, f := range files {
+		if f.Mode().IsRegular() && !strings.HasSuffix(f.Name(), tmpSuffix) {
+			ids = append(ids, f.Name())
+		}
+	}
+
+	return ids, nil
+}
+
+func (l fileLog) Read(id string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(l.dir, id))
+}
+
+func (l fileLog) Remove(id string) error {
+	if err := os.Remove(filepath.Join(l.dir, id)); err != nil {
+		return err
+	}
+
+	return syncDir(l.dir)
+}
+
+// Writes a file and syncs it to disk.
+func writeSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Syncs a directory, so that files created, renamed or removed within it
+// survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	err = d.Sync()
+
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Journal makes groups of writes to named stores atomic. Transactions are
+// recorded in a log before being applied, unless the journal is from
+// NewBoltJournal. A transaction which cannot be applied is rolled back, and
+// those a crash left partly applied are applied again by Recover. Writes must
+// therefore be idempotent, so list inserts keep values unique and removes of
+// missing values succeed.
+type Journal struct {
+	log   Log
+	blobs map[string]Blobs
+	lists map[string]Lists
+
+	// Database applying transactions in place of the log, if any.
+	bolt *Bolt
+
+	// Error stopping the journal after a record could not be settled.
+	err error
+
+	// Last transaction ID, so IDs increase even if the clock does not.
+	last int64
+	mu   sync.Mutex
+}
+
+// Tx is a transaction of writes which are applied together by Commit.
+type Tx struct {
+	j   *Journal
+	ops []op
+}
+
+type op struct {
+	Key   string `json:"key"`
+	Kind  string `json:"kind"`
+	Store string `json:"store"`
+	Value []byte `json:"value"`
+}
+
+// Previous value of a blob or list written by a transaction, restored if the
+// transaction is rolled back.
+type undo struct {
+	key    string
+	kind   string
+	store  string
+	exists bool
+	value  []byte
+	values []string
+}
+
+// NewJournal creates a journal keeping its records in log.
+func NewJournal(log Log) *Journal {
+	return &Journal{
+		log:   log,
+		blobs: make(map[string]Blobs),
+		lists: make(map[string]Lists),
+	}
+}
+
+// RegisterBlobs names blobs so transactions can write to them.
+func (j *Journal) RegisterBlobs(name string, blobs Blobs) {
+	j.blobs[name] = blobs
+}
+
+// RegisterLists names lists so transactions can insert into them.
+func (j *Journal) RegisterLists(name string, lists Lists) {
+	j.lists[name] = lists
+}
+
+// Begin starts a transaction.
+func (j *Journal) Begin() *Tx {
+	return &Tx{
+		j: j,
+	}
+}
+
+// Write writes a value to the named blobs.
+func (tx *Tx) Write(store, key string, value []byte) {
+	tx.ops = append(tx.ops, op{
+		Key:   key,
+		Kind:  opWrite,
+		Store: store,
+		Value: value,
+	})
+}
+
+// Insert appends a value to a list of the named lists if it is not already
+// present.
+func (tx *Tx) Insert(store, key, value string) {
+	tx.ops = append(tx.ops, op{
+		Key:   key,
+		Kind:  opInsert,
+		Store: store,
+		Value: []byte(value),
+	})
+}
+
+// Remove removes a value from the named blobs if it exists.
+func (tx *Tx) Remove(store, key string) {
+	tx.ops = append(tx.ops, op{
+		Key:   key,
+		Kind:  opRemove,
+		Store: store,
+	})
+}
+
+// RemoveList removes a list from the named lists if it exists.
+func (tx *Tx) RemoveList(store, key string) {
+	tx.ops = append(tx.ops, op{
+		Key:   key,
+		Kind:  opRemoveList,
+		Store: store,
+	})
+}
+
+// Commit records the transaction and applies its writes in order. Writes which
+// fail are attempted again, and if they still fail the transaction is rolled
+// back so none of its writes remain. Writers of the same keys must be excluded
+// until Commit returns.
+//
+// If rolling back fails too, the transaction stays recorded and is applied by
+// the next Recover. A record left behind would be replayed by Recover over
+// later transactions, so the journal then fails all commits until it is
+// recovered.
+func (tx *Tx) Commit() error {
+	if err := tx.j.stopped(); err != nil {
+		return err
+	}
+
+	for _, o := range tx.ops {
+		if err := tx.j.check(o); err != nil {
+			return err
+		}
+	}
+
+	if tx.j.bolt != nil {
+		return tx.j.commitBolt(tx.ops)
+	}
+
+	b, err := json.Marshal(tx.ops)
+
+	if err != nil {
+		return err
+	}
+
+	undos, err := tx.j.save(tx.ops)
+
+	if err != nil {
+		return err
+	}
+
+	id := tx.j.nextID()
+
+	if err = tx.j.log.Append(id, b); err != nil {
+		// The record may have been written before failing.
+		if serr := tx.j.settle(id, true); serr != nil {
+			return fmt.Errorf("%v, %v", err, serr)
+		}
+
+		return err
+	}
+
+	for i := 0; i < applyAttempts; i++ {
+		if err = tx.j.apply(tx.ops); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		if rerr := tx.j.rollback(undos); rerr != nil {
+			return tx.j.stop(fmt.Errorf("%v, rolling back: %v", err,
+				rerr))
+		}
+	}
+
+	if serr := tx.j.settle(id, err != nil); serr != nil {
+		return serr
+	}
+
+	return err
+}
+
+// Removes the record of a transaction which was applied or rolled back. A
+// rolled back transaction whose record cannot be removed is marked instead.
+// If neither succeeds, the journal is stopped.
+func (j *Journal) settle(id string, rolledBack bool) error {
+	var err error
+
+	for i := 0; i < applyAttempts; i++ {
+		if err = j.log.Remove(id); err == nil || os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	if rolledBack {
+		if merr := j.log.Append(id+rollbackSuffix, nil); merr == nil {
+			return nil
+		}
+	}
+
+	return j.stop(fmt.Errorf("removing record: %v", err))
+}
+
+// Stops the journal, so that commits fail until it is recovered.
+func (j *Journal) stop(err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.err = fmt.Errorf("journal stopped: %v", err)
+
+	return j.err
+}
+
+// Gets the error stopping the journal, if any.
+func (j *Journal) stopped() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.err
+}
+
+// Generates an increasing transaction ID, so transactions are recovered in the
+// order they were committed.
+func (j *Journal) nextID() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now().UnixNano()
+
+	if now <= j.last {
+		now = j.last + 1
+	}
+
+	j.last = now
+
+	return fmt.Sprintf("%016x", now)
+}
+
+// Recover applies the writes of transactions which were committed but not
+// finished, and discards those which were rolled back. It must be called
+// before the journal is used.
+func (j *Journal) Recover() error {
+	if j.bolt != nil {
+		return nil
+	}
+
+	ids, err := j.log.IDs()
+
+	if err != nil {
+		return err
+	}
+
+	var markers []string
+	rolledBack := make(map[string]bool)
+
+	for _, id := range ids {
+		if strings.HasSuffix(id, rollbackSuffix) {
+			markers = append(markers, id)
+			rolledBack[strings.TrimSuffix(id, rollbackSuffix)] = true
+		}
+	}
+
+	for _, id := range ids {
+		if strings.HasSuffix(id, rollbackSuffix) {
+			continue
+		}
+
+		if rolledBack[id] {
+			if err = j.log.Remove(id); err != nil &&
+				!os.IsNotExist(err) {
+				return err
+			}
+
+			continue
+		}
+
+		b, err := j.log.Read(id)
+
+		if err != nil {
+			return err
+		}
+
+		var ops []op
+
+		if err = json.Unmarshal(b, &ops); err != nil {
+			return err
+		}
+
+		for _, o := range ops {
+			if err = j.check(o); err != nil {
+				return err
+			}
+		}
+
+		if err = j.apply(ops); err != nil {
+			return err
+		}
+
+		if err = j.log.Remove(id); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Markers are removed after their records, so a record is never left
+	// without its marker.
+	for _, id := range markers {
+		if err = j.log.Remove(id); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	j.mu.Lock()
+	j.err = nil
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *Journal) check(o op) error {
+	switch o.Kind {
+	case opInsert, opRemoveList:
+		if _, ok := j.lists[o.Store]; !ok {
+			return errors.New("journal has no lists " + o.Store)
+		}
+	case opRemove, opWrite:
+		if _, ok := j.blobs[o.Store]; !ok {
+			return errors.New("journal has no blobs " + o.Store)
+		}
+	default:
+		return errors.New("journal op invalid")
+	}
+
+	return nil
+}
+
+func (j *Journal) apply(ops []op) error {
+	for _, o := range ops {
+		var err error
+
+		switch o.Kind {
+		case opInsert:
+			err = j.lists[o.Store].InsertUnique(o.Key, string(o.Value))
+		case opRemove:
+			err = j.blobs[o.Store].Remove(o.Key)
+		case opRemoveList:
+			err = j.lists[o.Store].Remove(o.Key)
+		case opWrite:
+			err = j.blobs[o.Store].Write(o.Key, o.Value)
+		}
+
+		// Removes are applied again by Recover, so a missing value is
+		// already removed.
+		if os.IsNotExist(err) && (o.Kind == opRemove ||
+			o.Kind == opRemoveList) {
+			err = nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Saves the values ops write before they are applied, once for each key.
+func (j *Journal) save(ops []op) ([]undo, error) {
+	var undos []undo
+	saved := make(map[string]bool)
+
+	for _, o := range ops {
+		name := "blob/" + o.Store + "/" + o.Key
+
+		if isList(o.Kind) {
+			name = "list/" + o.Store + "/" + o.Key
+		}
+
+		if saved[name] {
+			continue
+		}
+
+		saved[name] = true
+
+		u := undo{
+			key:   o.Key,
+			kind:  o.Kind,
+			store: o.Store,
+		}
+
+		var err error
+
+		if isList(o.Kind) {
+			u.values, err = j.lists[o.Store].IndexN(o.Key, 0)
+		} else {
+			u.value, err = j.blobs[o.Store].Read(o.Key)
+		}
+
+		if err == nil {
+			u.exists = true
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		undos = append(undos, u)
+	}
+
+	return undos, nil
+}
+
+// Restores the values saved before a transaction was applied.
+func (j *Journal) rollback(undos []undo) error {
+	for i := len(undos) - 1; i >= 0; i-- {
+		u := undos[i]
+		var err error
+
+		switch {
+		case isList(u.kind):
+			err = j.restoreList(u)
+		case u.exists:
+			err = j.blobs[u.store].Write(u.key, u.value)
+		default:
+			err = j.blobs[u.store].Remove(u.key)
+
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isList(kind string) bool {
+	return kind == opInsert || kind == opRemoveList
+}
+
+// Replaces a list with its saved values, unless it is unchanged.
+func (j *Journal) restoreList(u undo) error {
+	lists := j.lists[u.store]
+	values, err := lists.IndexN(u.key, 0)
+
+	if os.IsNotExist(err) {
+		if !u.exists {
+			return nil
+		}
+	} else if err != nil {
+		return err
+	} else if u.exists && len(values) == len(u.values) {
+		return nil
+	}
+
+	if err = lists.Remove(u.key); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, v := range u.values {
+		if err = lists.Insert(u.key, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
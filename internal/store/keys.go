@@ -0,0 +1,66 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// MasterKeyEnv is the environment variable master keys are read from when no
+// key file is given.
+const MasterKeyEnv = "RAMBLE_MASTER_KEY"
+
+// ParseMasterKeys parses hex master keys separated by commas or whitespace,
+// current key first.
+func ParseMasterKeys(s string) ([][]byte, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	var keys [][]byte
+
+	for _, f := range fields {
+		key, err := hex.DecodeString(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(key) != MasterKeyLen {
+			return nil, errors.New("master key length invalid")
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// LoadMasterKeys reads master keys from file, or from the MasterKeyEnv
+// environment variable if file is empty. Returns no keys if neither is set.
+func LoadMasterKeys(file string) ([][]byte, error) {
+	if file == "" {
+		return ParseMasterKeys(os.Getenv(MasterKeyEnv))
+	}
+
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseMasterKeys(string(b))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("key file holds no master keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,8 @@
+package store
+
+import "errors"
+
+// LockFile is the name of the file locked by LockDir in a data directory.
+const LockFile = "ramble.lock"
+
+var errLocked = errors.New("data directory is in use by another process")
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package store
+
+// LockDir does nothing on systems without flock, so data directories must not
+// be shared between processes there.
+func LockDir(dir string, shared bool) (func() error, error) {
+	return func() error {
+		return nil
+	}, nil
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// LockDir locks a data directory against use by other processes, returning a
+// function which unlocks it. Shared locks may be held by several processes at
+// once, but not with an exclusive lock. Locking fails rather than waits if the
+// directory is in use.
+func LockDir(dir string, shared bool) (func() error, error) {
+	f, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_RDONLY|
+		os.O_CREATE, 0600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_EX
+
+	if shared {
+		how = syscall.LOCK_SH
+	}
+
+	err = syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		err = errLocked
+	}
+
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	// Closing the file releases the lock.
+	return f.Close, nil
+}
@@ -0,0 +1,397 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	// MasterKeyLen is the length of a master key in bytes.
+	MasterKeyLen = 32
+
+	keyIDLen = 4
+)
+
+type sealKey struct {
+	id   []byte
+	name []byte
+	aead cipher.AEAD
+}
+
+// Sealer encrypts stored values and hides stored keys under a list of master
+// keys. The first master key seals new values. The others only open existing
+// values until Reseal rewrites them under the first key, after which they can
+// be dropped.
+type Sealer struct {
+	keys []*sealKey
+}
+
+// NewSealer creates a Sealer from master keys, current key first.
+func NewSealer(masters [][]byte) (*Sealer, error) {
+	if len(masters) == 0 {
+		return nil, errors.New("no master keys")
+	}
+
+	s := new(Sealer)
+
+	for _, master := range masters {
+		if len(master) != MasterKeyLen {
+			return nil, errors.New("master key length invalid")
+		}
+
+		sum := sha256.Sum256(master)
+
+		k := &sealKey{
+			id:   sum[:keyIDLen],
+			name: derive(master, "name"),
+		}
+
+		for _, other := range s.keys {
+			if hmac.Equal(k.id, other.id) {
+				return nil, errors.New("duplicate master key")
+			}
+		}
+
+		block, err := aes.NewCipher(derive(master, "value"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		k.aead, err = cipher.NewGCM(block)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.keys = append(s.keys, k)
+	}
+
+	return s, nil
+}
+
+// Derives a purpose-specific key from a master key.
+func derive(master []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, master)
+	_, _ = mac.Write([]byte("ramble store " + purpose))
+	return mac.Sum(nil)
+}
+
+// Hides a key name behind its HMAC.
+func (k *sealKey) hide(key string) string {
+	mac := hmac.New(sha256.New, k.name)
+	_, _ = mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Seals a value as the master key ID, nonce, and ciphertext. The key name is
+// sealed with the value so that Reseal can recover it, and its HMAC is
+// authenticated so sealed values cannot be swapped between keys.
+func (k *sealKey) seal(key string, value []byte) ([]byte, error) {
+	size := keyIDLen + k.aead.NonceSize()
+	sealed := make([]byte, size, size+binary.MaxVarintLen64+len(key)+
+		len(value)+k.aead.Overhead())
+
+	copy(sealed, k.id)
+
+	if _, err := io.ReadFull(rand.Reader, sealed[keyIDLen:]); err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+
+		len(key)+len(value))
+	plain = plain[:binary.PutUvarint(plain, uint64(len(key)))]
+	plain = append(plain, key...)
+	plain = append(plain, value...)
+
+	return k.aead.Seal(sealed, sealed[keyIDLen:], plain,
+		[]byte(k.hide(key))), nil
+}
+
+// Opens a value stored under hidden, the HMAC of its key name, returning the
+// key name and value.
+func (k *sealKey) open(hidden string, sealed []byte) (string, []byte, error) {
+	nonce := sealed[keyIDLen:]
+
+	if len(nonce) < k.aead.NonceSize() {
+		return "", nil, errors.New("sealed value too short")
+	}
+
+	ciphertext := nonce[k.aead.NonceSize():]
+	nonce = nonce[:k.aead.NonceSize()]
+
+	plain, err := k.aead.Open(nil, nonce, ciphertext, []byte(hidden))
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	n, size := binary.Uvarint(plain)
+
+	if size <= 0 || n > uint64(len(plain)-size) {
+		return "", nil, errors.New("sealed key name invalid")
+	}
+
+	plain = plain[size:]
+
+	return string(plain[:n]), plain[n:], nil
+}
+
+// Gets the master key a value is sealed under.
+func (s *Sealer) key(sealed []byte) (*sealKey, error) {
+	if len(sealed) < keyIDLen {
+		return nil, errors.New("sealed value too short")
+	}
+
+	for _, k := range s.keys {
+		if hmac.Equal(sealed[:keyIDLen], k.id) {
+			return k, nil
+		}
+	}
+
+	return nil, errors.New("sealed with unknown master key")
+}
+
+func (s *Sealer) open(key string, sealed []byte) ([]byte, error) {
+	k, err := s.key(sealed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, value, err := k.open(k.hide(key), sealed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if name != key {
+		return nil, errors.New("sealed key name mismatch")
+	}
+
+	return value, nil
+}
+
+// Keys gets the key names of the values of raw, blobs as passed to Blobs, in
+// ascending order. Hidden are the stored keys of raw, such as from
+// Backend.Keys.
+func (s *Sealer) Keys(raw Blobs, hidden []string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+
+	for _, h := range hidden {
+		sealed, err := raw.Read(h)
+
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := s.key(sealed)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", h, err)
+		}
+
+		key, _, err := k.open(h, sealed)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", h, err)
+		}
+
+		// A crash while writing may leave copies under two master
+		// keys.
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Reseal rewrites the values of raw, blobs as passed to Blobs, which are sealed
+// under an old master key so that they are sealed under the current one.
+// Hidden are the stored keys of raw, such as from Backend.Keys. Returns the
+// number of values resealed. Raw must not be otherwise in use.
+func (s *Sealer) Reseal(raw Blobs, hidden []string) (int, error) {
+	current := s.keys[0]
+	resealed := 0
+
+	for _, h := range hidden {
+		sealed, err := raw.Read(h)
+
+		if err != nil {
+			return resealed, err
+		}
+
+		k, err := s.key(sealed)
+
+		if err != nil {
+			return resealed, fmt.Errorf("%s: %v", h, err)
+		}
+
+		if k == current {
+			continue
+		}
+
+		key, value, err := k.open(h, sealed)
+
+		if err != nil {
+			return resealed, fmt.Errorf("%s: %v", h, err)
+		}
+
+		// Writes seal under the current key before removing old
+		// copies, so a copy under the current key is newer.
+		name := current.hide(key)
+		_, err = raw.Read(name)
+
+		if os.IsNotExist(err) {
+			if sealed, err = current.seal(key, value); err != nil {
+				return resealed, err
+			}
+
+			if err = raw.Write(name, sealed); err != nil {
+				return resealed, err
+			}
+		} else if err != nil {
+			return resealed, err
+		}
+
+		if err = raw.Remove(h); err != nil {
+			return resealed, err
+		}
+
+		resealed++
+	}
+
+	return resealed, nil
+}
+
+type sealedBlobs struct {
+	sealer *Sealer
+	blobs  Blobs
+}
+
+// Blobs wraps blobs so keys are stored as HMACs and values are encrypted.
+func (s *Sealer) Blobs(blobs Blobs) Blobs {
+	return &sealedBlobs{
+		sealer: s,
+		blobs:  blobs,
+	}
+}
+
+// Reads the value under the current master key, or else under an old key.
+func (b *sealedBlobs) Read(key string) ([]byte, error) {
+	var notExist error
+
+	for _, k := range b.sealer.keys {
+		sealed, err := b.blobs.Read(k.hide(key))
+
+		if os.IsNotExist(err) {
+			if notExist == nil {
+				notExist = err
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return b.sealer.open(key, sealed)
+	}
+
+	return nil, notExist
+}
+
+func (b *sealedBlobs) Write(key string, value []byte) error {
+	current := b.sealer.keys[0]
+	sealed, err := current.seal(key, value)
+
+	if err != nil {
+		return err
+	}
+
+	if err = b.blobs.Write(current.hide(key), sealed); err != nil {
+		return err
+	}
+
+	for _, k := range b.sealer.keys[1:] {
+		err = b.blobs.Remove(k.hide(key))
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *sealedBlobs) Remove(key string) error {
+	var notExist error
+	removed := false
+
+	for _, k := range b.sealer.keys {
+		err := b.blobs.Remove(k.hide(key))
+
+		if os.IsNotExist(err) {
+			if notExist == nil {
+				notExist = err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		removed = true
+	}
+
+	if !removed {
+		return notExist
+	}
+
+	return nil
+}
+
+type sealedLog struct {
+	Log
+	sealer *Sealer
+}
+
+// Log wraps a log so its records are encrypted.
+func (s *Sealer) Log(log Log) Log {
+	return &sealedLog{
+		Log:    log,
+		sealer: s,
+	}
+}
+
+func (l *sealedLog) Append(id string, record []byte) error {
+	sealed, err := l.sealer.keys[0].seal(id, record)
+
+	if err != nil {
+		return err
+	}
+
+	return l.Log.Append(id, sealed)
+}
+
+func (l *sealedLog) Read(id string) ([]byte, error) {
+	sealed, err := l.Log.Read(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l.sealer.open(id, sealed)
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Prefix of files being written, which are not values.
+const tempPrefix = "."
+
+var errKey = errors.New("key invalid")
+
+// Blobs kept as a file named by each key, in directories named by the key's
+// first characters so that no one directory grows too large.
+type splay struct {
+	depth int
+	dir   string
+}
+
+func newSplay(dir string, depth int) (*splay, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &splay{
+		depth: depth,
+		dir:   dir,
+	}, nil
+}
+
+// Gets the path of a key's file. Keys shorter than the depth are kept at the
+// depth of their length.
+func (s *splay) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, tempPrefix) ||
+		strings.ContainsAny(key, `/\`) {
+		return "", errKey
+	}
+
+	elem := []string{s.dir}
+
+	for i := 0; i < s.depth && i < len(key); i++ {
+		elem = append(elem, key[i:i+1])
+	}
+
+	return filepath.Join(append(elem, key)...), nil
+}
+
+func (s *splay) Read(key string) ([]byte, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// Write replaces the value of a key. The value is synced to a temporary file
+// which is then renamed over the old value, so a crash leaves one or the
+// other.
+func (s *splay) Write(key string, value []byte) (err error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, tempPrefix+key)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(value); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func (s *splay) Remove(key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,156 @@
+// Package store implements the storage used by a ramble server.
+package store
+
+import (
+	"errors"
+	"os"
+)
+
+// Blobs stores values by key.
+type Blobs interface {
+	Read(key string) ([]byte, error)
+	Write(key string, value []byte) error
+	Remove(key string) error
+}
+
+// Lists stores lists of fixed-length values by key.
+type Lists interface {
+	// IndexN gets the first n values of a list, or all values if n is 0.
+	IndexN(key string, n uint64) ([]string, error)
+
+	// Insert appends a value to a list.
+	Insert(key, value string) error
+
+	// InsertUnique appends a value to a list if it is not already present.
+	InsertUnique(key, value string) error
+
+	// Remove removes a list.
+	Remove(key string) error
+}
+
+type lists struct {
+	blobs Blobs
+	vlen  int
+}
+
+// NewLists stores each list in blobs as its values concatenated. Values must
+// be vlen bytes long.
+func NewLists(blobs Blobs, vlen int) Lists {
+	return &lists{
+		blobs: blobs,
+		vlen:  vlen,
+	}
+}
+
+func (l *lists) IndexN(key string, n uint64) ([]string, error) {
+	b, err := l.blobs.Read(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+
+	for i := 0; i+l.vlen <= len(b); i += l.vlen {
+		if n != 0 && uint64(len(values)) == n {
+			break
+		}
+
+		values = append(values, string(b[i:i+l.vlen]))
+	}
+
+	return values, nil
+}
+
+func (l *lists) Insert(key, value string) error {
+	return l.insert(key, value, false)
+}
+
+func (l *lists) InsertUnique(key, value string) error {
+	return l.insert(key, value, true)
+}
+
+func (l *lists) insert(key, value string, unique bool) error {
+	if len(value) != l.vlen {
+		return errors.New("value length invalid")
+	}
+
+	b, err := l.blobs.Read(key)
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if unique {
+		for i := 0; i+l.vlen <= len(b); i += l.vlen {
+			if string(b[i:i+l.vlen]) == value {
+				return nil
+			}
+		}
+	}
+
+	return l.blobs.Write(key, append(b, value...))
+}
+
+func (l *lists) Remove(key string) error {
+	return l.blobs.Remove(key)
+}
+
+var errReadOnly = errors.New("storage is read-only")
+
+// Blobs of read-only storage.
+type readOnlyBlobs struct {
+	Blobs
+}
+
+func (readOnlyBlobs) Write(string, []byte) error {
+	return errReadOnly
+}
+
+func (readOnlyBlobs) Remove(string) error {
+	return errReadOnly
+}
+
+// Lists of read-only storage.
+type readOnlyLists struct {
+	Lists
+}
+
+func (readOnlyLists) Insert(string, string) error {
+	return errReadOnly
+}
+
+func (readOnlyLists) InsertUnique(string, string) error {
+	return errReadOnly
+}
+
+func (readOnlyLists) Remove(string) error {
+	return errReadOnly
+}
+
+// Blobs or lists of read-only storage which do not exist.
+type emptyStore struct{}
+
+func (emptyStore) Read(string) ([]byte, error) {
+	return nil, os.ErrNotExist
+}
+
+func (emptyStore) IndexN(string, uint64) ([]string, error) {
+	return nil, os.ErrNotExist
+}
+
+func (emptyStore) Write(string, []byte) error {
+	return errReadOnly
+}
+
+func (emptyStore) Insert(string, string) error {
+	return errReadOnly
+}
+
+func (emptyStore) InsertUnique(string, string) error {
+	return errReadOnly
+}
+
+func (emptyStore) Remove(string) error {
+	return errReadOnly
+}
@@ -0,0 +1,1140 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Blobs kept in memory.
+type memBlobs map[string][]byte
+
+func (m memBlobs) Read(key string) ([]byte, error) {
+	v, ok := m[key]
+
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return append([]byte{}, v...), nil
+}
+
+func (m memBlobs) Write(key string, value []byte) error {
+	m[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (m memBlobs) Remove(key string) error {
+	if _, ok := m[key]; !ok {
+		return os.ErrNotExist
+	}
+
+	delete(m, key)
+	return nil
+}
+
+func masterKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, MasterKeyLen)
+}
+
+func newSealer(t *testing.T, masters ...[]byte) *Sealer {
+	s, err := NewSealer(masters)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// TestSplay checks values are kept by key, keys which are not file names are
+// rejected, and files being written are not listed as keys.
+func TestSplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "splay")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s, err := newSplay(filepath.Join(dir, "b"), splayDepth)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"k", "key"} {
+		if err = s.Write(k, []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = s.Write(k, []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+
+		if v, err := s.Read(k); err != nil || string(v) != k {
+			t.Fatalf("read %s: %q, %v", k, v, err)
+		}
+	}
+
+	for _, k := range []string{"", ".key", "../key", "k/ey"} {
+		if err = s.Write(k, nil); err != errKey {
+			t.Fatalf("key %q accepted", k)
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "b", "k", tempPrefix+"k"),
+		nil, 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewFiles(dir).Keys("b", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(keys, " ") != "k key" {
+		t.Fatalf("keys %q", keys)
+	}
+
+	if err = s.Remove("key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Read("key"); !os.IsNotExist(err) {
+		t.Fatalf("removed key read: %v", err)
+	}
+}
+
+func TestLists(t *testing.T) {
+	l := NewLists(make(memBlobs), 2)
+
+	if _, err := l.IndexN("k", 0); !os.IsNotExist(err) {
+		t.Fatalf("missing list: %v", err)
+	}
+
+	for _, v := range []string{"aa", "bb", "aa"} {
+		if err := l.Insert("k", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := l.InsertUnique("k", "bb"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Insert("k", "ccc"); err == nil {
+		t.Fatal("value of wrong length inserted")
+	}
+
+	values, err := l.IndexN("k", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(values, ",") != "aa,bb,aa" {
+		t.Fatalf("values mismatch: %v", values)
+	}
+
+	if values, err = l.IndexN("k", 2); err != nil || len(values) != 2 {
+		t.Fatalf("IndexN returned %v, %v", values, err)
+	}
+}
+
+func TestSealerBlobs(t *testing.T) {
+	m := make(memBlobs)
+	b := newSealer(t, masterKey(1)).Blobs(m)
+
+	if err := b.Write("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range m {
+		if strings.Contains(k, "key") || bytes.Contains(v, []byte("value")) {
+			t.Fatal("plaintext stored")
+		}
+	}
+
+	v, err := b.Read("key")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(v) != "value" {
+		t.Fatal("value mismatch")
+	}
+
+	if _, err = b.Read("other"); !os.IsNotExist(err) {
+		t.Fatalf("missing key: %v", err)
+	}
+
+	if err = b.Remove("key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Remove("key"); !os.IsNotExist(err) {
+		t.Fatalf("removed missing key: %v", err)
+	}
+
+	if _, err = b.Read("key"); !os.IsNotExist(err) {
+		t.Fatalf("removed key: %v", err)
+	}
+}
+
+// TestSealerSwap checks that sealed values are bound to their key.
+func TestSealerSwap(t *testing.T) {
+	m := make(memBlobs)
+	s := newSealer(t, masterKey(1))
+	b := s.Blobs(m)
+
+	if err := b.Write("a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Write("b", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	k := s.keys[0]
+	m[k.hide("a")], m[k.hide("b")] = m[k.hide("b")], m[k.hide("a")]
+
+	if _, err := b.Read("a"); err == nil {
+		t.Fatal("swapped value opened")
+	}
+}
+
+// TestSealerRotate checks that values sealed under an old master key are read
+// without being changed, and are rewritten under the current key by Reseal.
+func TestSealerRotate(t *testing.T) {
+	m := make(memBlobs)
+	old, current := masterKey(1), masterKey(2)
+	b := newSealer(t, old).Blobs(m)
+
+	for _, key := range []string{"a", "b"} {
+		if err := b.Write(key, []byte("old "+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := newSealer(t, current).Blobs(m).Read("a"); err == nil {
+		t.Fatal("opened without old master key")
+	}
+
+	s := newSealer(t, current, old)
+	before := len(m)
+	v, err := s.Blobs(m).Read("a")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(v) != "old a" || len(m) != before {
+		t.Fatal("value changed by read")
+	}
+
+	// A crash while writing may leave a copy under the old key behind.
+	stale := m[s.keys[1].hide("b")]
+
+	if err = s.Blobs(m).Write("b", []byte("new b")); err != nil {
+		t.Fatal(err)
+	}
+
+	m[s.keys[1].hide("b")] = stale
+
+	hidden := make([]string, 0, len(m))
+
+	for k := range m {
+		hidden = append(hidden, k)
+	}
+
+	n, err := s.Reseal(m, hidden)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 || len(m) != 2 {
+		t.Fatalf("resealed %d values, %d stored", n, len(m))
+	}
+
+	b = newSealer(t, current).Blobs(m)
+
+	for key, want := range map[string]string{"a": "old a", "b": "new b"} {
+		if v, err = b.Read(key); err != nil || string(v) != want {
+			t.Fatalf("%s: %q, %v after reseal", key, v, err)
+		}
+	}
+}
+
+// TestSealerKeys checks that the key names of sealed values can be listed.
+func TestSealerKeys(t *testing.T) {
+	m := make(memBlobs)
+	s := newSealer(t, masterKey(2), masterKey(1))
+
+	for _, key := range []string{"b", "a"} {
+		if err := newSealer(t, masterKey(1)).Blobs(m).Write(key,
+			[]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Blobs(m).Write("c", []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	hidden := make([]string, 0, len(m))
+
+	for k := range m {
+		hidden = append(hidden, k)
+	}
+
+	keys, err := s.Keys(m, hidden)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(keys, ",") != "a,b,c" {
+		t.Fatalf("keys %v", keys)
+	}
+}
+
+func TestParseMasterKeys(t *testing.T) {
+	a := strings.Repeat("01", MasterKeyLen)
+	b := strings.Repeat("02", MasterKeyLen)
+
+	keys, err := ParseMasterKeys(a + ",\n" + b + "\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || !bytes.Equal(keys[1], masterKey(2)) {
+		t.Fatal("keys mismatch")
+	}
+
+	if _, err = ParseMasterKeys(a[2:]); err == nil {
+		t.Fatal("short key accepted")
+	}
+
+	if _, err = NewSealer([][]byte{masterKey(1), masterKey(1)}); err == nil {
+		t.Fatal("duplicate key accepted")
+	}
+}
+
+var errCrash = errors.New("crashed")
+
+// Blobs which fail all writes once a shared number of writes is used up, as if
+// the process crashed.
+type crashBlobs struct {
+	Blobs
+	writes *int
+}
+
+func (c crashBlobs) Write(key string, value []byte) error {
+	if *c.writes == 0 {
+		return errCrash
+	}
+
+	*c.writes--
+	return c.Blobs.Write(key, value)
+}
+
+func (c crashBlobs) Remove(key string) error {
+	if *c.writes == 0 {
+		return errCrash
+	}
+
+	*c.writes--
+	return c.Blobs.Remove(key)
+}
+
+// A log which crashes like crashBlobs. If late is set, a record is written
+// before the crash is reported, as if the process crashed before Append
+// returned.
+type crashLog struct {
+	Log
+	writes *int
+	late   bool
+}
+
+func (c crashLog) Append(id string, record []byte) error {
+	if *c.writes == 0 {
+		if c.late {
+			_ = c.Log.Append(id, record)
+		}
+
+		return errCrash
+	}
+
+	*c.writes--
+	return c.Log.Append(id, record)
+}
+
+func (c crashLog) Remove(id string) error {
+	if *c.writes == 0 {
+		return errCrash
+	}
+
+	*c.writes--
+	return c.Log.Remove(id)
+}
+
+// TestJournalCrash crashes a transaction during each of its writes, including
+// those of its log record, and checks that after recovery either all or none of
+// its writes are applied.
+func TestJournalCrash(t *testing.T) {
+	for _, late := range []bool{false, true} {
+		for n := 0; ; n++ {
+			log := NewFileLog(t.TempDir())
+			blobs, lists := make(memBlobs), make(memBlobs)
+			writes := n
+
+			j := NewJournal(crashLog{log, &writes, late})
+			j.RegisterBlobs("b", crashBlobs{blobs, &writes})
+			j.RegisterLists("l", NewLists(crashBlobs{lists, &writes}, 2))
+
+			tx := j.Begin()
+			tx.Write("b", "msg", []byte("value"))
+			tx.Insert("l", "a", "mm")
+			tx.Insert("l", "b", "mm")
+			err := tx.Commit()
+
+			if err != nil && !strings.Contains(err.Error(),
+				errCrash.Error()) {
+				t.Fatal(err)
+			}
+
+			j = NewJournal(log)
+			j.RegisterBlobs("b", blobs)
+			j.RegisterLists("l", NewLists(lists, 2))
+
+			if err := j.Recover(); err != nil {
+				t.Fatalf("late=%t n=%d: %v", late, n, err)
+			}
+
+			if ids, err := log.IDs(); err != nil || len(ids) != 0 {
+				t.Fatalf("late=%t n=%d: records %v, %v after"+
+					" recovery", late, n, ids, err)
+			}
+
+			_, committed := blobs["msg"]
+
+			if err == nil && !committed {
+				t.Fatalf("late=%t n=%d: committed without value",
+					late, n)
+			}
+
+			for _, key := range []string{"a", "b"} {
+				values, err := j.lists["l"].IndexN(key, 0)
+
+				if committed && (err != nil || len(values) != 1) {
+					t.Fatalf("late=%t n=%d: list %s is %v, %v",
+						late, n, key, values, err)
+				} else if !committed && !os.IsNotExist(err) {
+					t.Fatalf("late=%t n=%d: list %s inserted",
+						late, n, key)
+				}
+			}
+
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// Blobs which fail writes to one key.
+type failBlobs struct {
+	Blobs
+	key   string
+	fails *int
+}
+
+func (f failBlobs) Write(key string, value []byte) error {
+	if key == f.key && *f.fails != 0 {
+		*f.fails--
+		return errCrash
+	}
+
+	return f.Blobs.Write(key, value)
+}
+
+// TestJournalRollback checks that a transaction whose writes keep failing is
+// rolled back, and that one whose writes fail briefly is applied.
+func TestJournalRollback(t *testing.T) {
+	for _, fails := range []int{applyAttempts, applyAttempts - 1} {
+		log := NewFileLog(t.TempDir())
+		blobs, lists := make(memBlobs), make(memBlobs)
+		n := fails
+
+		j := NewJournal(log)
+		j.RegisterBlobs("b", blobs)
+		j.RegisterLists("l", NewLists(failBlobs{lists, "b", &n}, 2))
+
+		blobs["msg"] = []byte("old")
+		blobs["old"] = []byte("old")
+		lists["a"] = []byte("xx")
+		lists["c"] = []byte("yy")
+
+		tx := j.Begin()
+		tx.Write("b", "msg", []byte("value"))
+		tx.Write("b", "new", []byte("value"))
+		tx.Remove("b", "missing")
+		tx.Remove("b", "old")
+		tx.Insert("l", "a", "mm")
+		tx.RemoveList("l", "c")
+		tx.Insert("l", "b", "mm")
+		err := tx.Commit()
+
+		if ids, err := log.IDs(); err != nil || len(ids) != 0 {
+			t.Fatalf("fails=%d: records %v, %v", fails, ids, err)
+		}
+
+		if fails < applyAttempts {
+			if err != nil {
+				t.Fatalf("fails=%d: %v", fails, err)
+			}
+
+			_, oldOk := blobs["old"]
+			_, cOk := lists["c"]
+
+			if string(blobs["msg"]) != "value" ||
+				string(lists["a"]) != "xxmm" ||
+				string(lists["b"]) != "mm" || oldOk || cOk {
+				t.Fatalf("fails=%d: writes not applied", fails)
+			}
+
+			continue
+		}
+
+		if err == nil {
+			t.Fatal("failing transaction committed")
+		}
+
+		if string(blobs["msg"]) != "old" || string(blobs["old"]) != "old" ||
+			string(lists["a"]) != "xx" || string(lists["c"]) != "yy" {
+			t.Fatal("previous values not restored")
+		}
+
+		if _, ok := blobs["new"]; ok {
+			t.Fatal("new value not removed")
+		}
+
+		if _, ok := lists["b"]; ok {
+			t.Fatal("new list not removed")
+		}
+	}
+}
+
+// A log which fails to remove records.
+type stuckLog struct {
+	Log
+	fails *int
+}
+
+func (l stuckLog) Remove(id string) error {
+	if *l.fails != 0 {
+		*l.fails--
+		return errCrash
+	}
+
+	return l.Log.Remove(id)
+}
+
+// TestJournalLeftover checks records which cannot be removed are not replayed by
+// Recover over later transactions, nor replayed at all if rolled back.
+func TestJournalLeftover(t *testing.T) {
+	log := NewFileLog(t.TempDir())
+	blobs := make(memBlobs)
+	fails := applyAttempts
+
+	j := NewJournal(stuckLog{log, &fails})
+	j.RegisterBlobs("b", blobs)
+
+	tx := j.Begin()
+	tx.Write("b", "msg", []byte("value"))
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("record left behind without error")
+	}
+
+	tx = j.Begin()
+	tx.Remove("b", "msg")
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("committed over a record left behind")
+	}
+
+	if err := j.Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx = j.Begin()
+	tx.Remove("b", "msg")
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := blobs["msg"]; ok {
+		t.Fatal("removed value restored by Recover")
+	}
+
+	// A rolled back transaction whose record stays is marked.
+	n := applyAttempts
+	j.RegisterBlobs("b", failBlobs{blobs, "msg", &n})
+	fails = applyAttempts
+
+	tx = j.Begin()
+	tx.Write("b", "msg", []byte("value"))
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("failing transaction committed")
+	}
+
+	if err := j.Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := blobs["msg"]; ok {
+		t.Fatal("rolled back transaction applied by Recover")
+	}
+
+	if ids, err := log.IDs(); err != nil || len(ids) != 0 {
+		t.Fatalf("records %v, %v after recovery", ids, err)
+	}
+}
+
+// TestJournalSealed checks sealed log records are encrypted and recovered.
+func TestJournalSealed(t *testing.T) {
+	dir := t.TempDir()
+	log := newSealer(t, masterKey(1)).Log(NewFileLog(dir))
+
+	if err := log.Append("id", []byte("record")); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := NewFileLog(dir).Read("id")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(raw, []byte("record")) {
+		t.Fatal("record not encrypted")
+	}
+
+	if b, err := log.Read("id"); err != nil || string(b) != "record" {
+		t.Fatalf("record %q, %v", b, err)
+	}
+}
+
+func TestJournalUnknownStore(t *testing.T) {
+	j := NewJournal(NewFileLog(t.TempDir()))
+	tx := j.Begin()
+	tx.Write("missing", "key", nil)
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("write to unknown store committed")
+	}
+}
+
+func openBolt(t *testing.T, path string) *Bolt {
+	b, err := OpenBolt(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = b.Close()
+	})
+
+	return b
+}
+
+func TestBolt(t *testing.T) {
+	db := openBolt(t, filepath.Join(t.TempDir(), "bolt.db"))
+	b, err := db.Blobs("b")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = b.Read("key"); !os.IsNotExist(err) {
+		t.Fatalf("missing key: %v", err)
+	}
+
+	if err = b.Write("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := b.Read("key"); err != nil || string(v) != "value" {
+		t.Fatalf("read %q, %v", v, err)
+	}
+
+	if err = b.Remove("key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Remove("key"); !os.IsNotExist(err) {
+		t.Fatalf("removed missing key: %v", err)
+	}
+
+	l, err := db.Lists("l", 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = l.IndexN("k", 0); !os.IsNotExist(err) {
+		t.Fatalf("missing list: %v", err)
+	}
+
+	for _, v := range []string{"cc", "aa", "cc"} {
+		if err = l.Insert("k", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, v := range []string{"aa", "bb"} {
+		if err = l.InsertUnique("k", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = l.Insert("k", "ddd"); err == nil {
+		t.Fatal("value of wrong length inserted")
+	}
+
+	values, err := l.IndexN("k", 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(values, ",") != "cc,aa,cc,bb" {
+		t.Fatalf("values mismatch: %v", values)
+	}
+
+	if values, err = l.IndexN("k", 2); err != nil || len(values) != 2 {
+		t.Fatalf("IndexN returned %v, %v", values, err)
+	}
+
+	if err = l.Remove("k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = l.Remove("k"); !os.IsNotExist(err) {
+		t.Fatalf("removed missing list: %v", err)
+	}
+}
+
+// TestBoltMigrate checks databases keep their schema version, and that
+// databases of a newer schema are not opened.
+func TestBoltMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bolt.db")
+	db := openBolt(t, path)
+
+	version, err := db.Version()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != BoltVersion {
+		t.Fatalf("version %d, want %d", version, BoltVersion)
+	}
+
+	b, err := db.Blobs("b")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Write("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db = openBolt(t, path)
+
+	if b, err = db.Blobs("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = b.Read("key"); err != nil {
+		t.Fatal("value lost on reopen")
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := bolt.Open(path, 0600, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = raw.Update(func(tx *bolt.Tx) error {
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, BoltVersion+1)
+
+		return tx.Bucket(bucketMeta).Put(keyVersion, v)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = raw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenBolt(path)
+
+	if err == nil {
+		t.Fatal("newer schema opened")
+	}
+}
+
+// TestBackendKeys checks both backends list the keys of their stores in order.
+func TestBackendKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, backend := range []Backend{
+		NewFiles(dir),
+		openBolt(t, filepath.Join(dir, "bolt.db")),
+	} {
+		if keys, err := backend.Keys("b", false); err != nil ||
+			len(keys) != 0 {
+			t.Fatalf("missing store has keys %v, %v", keys, err)
+		}
+
+		b, err := backend.Blobs("b")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := backend.Lists("l", 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, k := range []string{"kc", "ka", "kb"} {
+			if err = b.Write(k, []byte("value")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = l.Insert(k, "vv"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, lists := range []bool{false, true} {
+			name := "b"
+
+			if lists {
+				name = "l"
+			}
+
+			keys, err := backend.Keys(name, lists)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(keys, ",") != "ka,kb,kc" {
+				t.Fatalf("keys mismatch: %v", keys)
+			}
+		}
+	}
+}
+
+// TestBackendCompact checks both backends keep their data when compacted,
+// after an interrupted compaction, and that stores stay usable.
+func TestBackendCompact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bolt.db")
+
+	err := ioutil.WriteFile(path+".compact", []byte("partial"), 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, backend := range []Backend{
+		NewFiles(dir),
+		openBolt(t, path),
+	} {
+		b, err := backend.Blobs("b")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 100; i++ {
+			err = b.Write(fmt.Sprintf("k%02d", i), bytes.Repeat([]byte{1},
+				1024))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i := 1; i < 100; i++ {
+			if err = b.Remove(fmt.Sprintf("k%02d", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err = backend.Compact(); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := b.Read("k00")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(v) != 1024 {
+			t.Fatal("value lost on compacting")
+		}
+
+		keys, err := backend.Keys("b", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(keys) != 1 {
+			t.Fatalf("keys mismatch: %v", keys)
+		}
+	}
+}
+
+// TestBackendReadOnly checks both backends opened read-only read the stored
+// data, treat missing stores as empty and fail to write.
+func TestBackendReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bolt.db")
+	db := openBolt(t, path)
+
+	for _, backend := range []Backend{NewFiles(dir), db} {
+		b, err := backend.Blobs("b")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := backend.Lists("l", 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = b.Write("k", []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = l.Insert("k", "vv"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenBoltReadOnly(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	for _, backend := range []Backend{NewReadOnlyFiles(dir), db} {
+		b, err := backend.Blobs("b")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := backend.Lists("l", 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v, err := b.Read("k"); err != nil || string(v) != "value" {
+			t.Fatalf("read %q, %v", v, err)
+		}
+
+		if v, err := l.IndexN("k", 0); err != nil || len(v) != 1 {
+			t.Fatalf("read list %v, %v", v, err)
+		}
+
+		if err = b.Write("k", nil); err == nil {
+			t.Fatal("read-only blobs written")
+		}
+
+		if err = l.Insert("k", "ww"); err == nil {
+			t.Fatal("read-only list inserted")
+		}
+
+		missing, err := backend.Blobs("m")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = missing.Read("k"); !os.IsNotExist(err) {
+			t.Fatalf("missing store read: %v", err)
+		}
+
+		if err = backend.Compact(); err == nil {
+			t.Fatal("read-only backend compacted")
+		}
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "m")); !os.IsNotExist(err) {
+		t.Fatal("read-only files created store")
+	}
+}
+
+// TestLockDir checks an exclusive lock excludes all others, while shared locks
+// only exclude exclusive ones.
+func TestLockDir(t *testing.T) {
+	dir := t.TempDir()
+	unlock, err := LockDir(dir, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, shared := range []bool{false, true} {
+		if _, err = LockDir(dir, shared); err == nil {
+			t.Fatalf("locked twice, shared %t", shared)
+		}
+	}
+
+	if err = unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		unlock, err = LockDir(dir, true)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer unlock()
+	}
+
+	if _, err = LockDir(dir, false); err == nil {
+		t.Fatal("exclusive lock taken while shared")
+	}
+}
+
+// TestBoltJournal checks a journal from NewBoltJournal applies all or none of a
+// transaction, including through sealed stores.
+func TestBoltJournal(t *testing.T) {
+	b := openBolt(t, filepath.Join(t.TempDir(), "bolt.db"))
+	sealer := newSealer(t, masterKey(1))
+
+	blobs, err := b.Blobs("b")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists, err := b.Lists("l", 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := b.Blobs("s")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j := NewBoltJournal(b)
+	j.RegisterBlobs("b", blobs)
+	j.RegisterLists("l", lists)
+	j.RegisterLists("s", NewLists(sealer.Blobs(sealed), 2))
+
+	tx := j.Begin()
+	tx.Write("b", "msg", []byte("value"))
+	tx.Insert("l", "a", "mm")
+	tx.Insert("s", "a", "mm")
+	tx.Insert("l", "b", "too long")
+
+	if err = tx.Commit(); err == nil {
+		t.Fatal("invalid transaction committed")
+	}
+
+	if _, err = blobs.Read("msg"); !os.IsNotExist(err) {
+		t.Fatal("value of failed transaction written")
+	}
+
+	if _, err = lists.IndexN("a", 0); !os.IsNotExist(err) {
+		t.Fatal("list of failed transaction inserted")
+	}
+
+	tx = j.Begin()
+	tx.Write("b", "msg", []byte("value"))
+	tx.Insert("l", "a", "mm")
+	tx.Insert("s", "a", "mm")
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := blobs.Read("msg"); err != nil || string(v) != "value" {
+		t.Fatalf("value %q, %v", v, err)
+	}
+
+	values, err := j.lists["s"].IndexN("a", 0)
+
+	if err != nil || len(values) != 1 {
+		t.Fatalf("sealed list %v, %v", values, err)
+	}
+
+	j.RegisterBlobs("m", make(memBlobs))
+	tx = j.Begin()
+	tx.Write("m", "msg", nil)
+
+	if err = tx.Commit(); err == nil {
+		t.Fatal("store outside database committed")
+	}
+}
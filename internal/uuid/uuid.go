@@ -1,9 +1,8 @@
 package uuid
 
 import (
+	"crypto/rand"
 	"encoding/hex"
-
-	"github.com/esote/util/uuid"
 )
 
 // LenUUID is the hex length of a UUID.
@@ -11,14 +10,11 @@ const LenUUID = 32
 
 // UUID generates a new UUID string.
 func UUID() (string, error) {
-	u, err := uuid.NewUUID()
+	u := make([]byte, LenUUID/2)
 
-	if err != nil {
+	if _, err := rand.Read(u); err != nil {
 		return "", err
 	}
 
-	h := make([]byte, LenUUID)
-	_ = hex.Encode(h, u)
-
-	return string(h), nil
+	return hex.EncodeToString(u), nil
 }
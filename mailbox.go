@@ -0,0 +1,44 @@
+package ramble
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MailboxToken derives the secret token of a blinded mailbox from a key held
+// only by the client. The token never leaves the client, and cannot be linked
+// to the fingerprint without the key.
+func MailboxToken(key []byte, fingerprint string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(strings.ToLower(fingerprint)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MailboxAddress derives the public address of a blinded mailbox from its
+// token, as the SHA-256 hash of the mailbox's Ed25519 public key. Clients share
+// their mailbox address with whoever should be able to add conversations to it.
+func MailboxAddress(token string) string {
+	public := mailboxKey(token).Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:])
+}
+
+// MailboxSign proves possession of a blinded mailbox by signing a hello
+// response nonce with the mailbox's Ed25519 key. The signature is the public
+// key followed by the signature, hex encoded.
+func MailboxSign(token, nonce string) string {
+	key := mailboxKey(token)
+	public := key.Public().(ed25519.PublicKey)
+	sig := ed25519.Sign(key, []byte(nonce))
+
+	return hex.EncodeToString(public) + hex.EncodeToString(sig)
+}
+
+// Derives the signing key of a blinded mailbox from its token.
+func mailboxKey(token string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte(strings.ToLower(token)))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
@@ -0,0 +1,2079 @@
+// Protocol buffer definition of the ramble protocol. Messages mirror the JSON
+// types of the ramble package, see there for field documentation.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: proto/ramble.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HelloResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         string                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloResponse) Reset() {
+	*x = HelloResponse{}
+	mi := &file_proto_ramble_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloResponse) ProtoMessage() {}
+
+func (x *HelloResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloResponse.ProtoReflect.Descriptor instead.
+func (*HelloResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HelloResponse) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *HelloResponse) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sig           string                 `protobuf:"bytes,1,opt,name=sig,proto3" json:"sig,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_proto_ramble_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VerifyRequest) GetSig() string {
+	if x != nil {
+		return x.Sig
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type WelcomeHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Public        string                 `protobuf:"bytes,1,opt,name=public,proto3" json:"public,omitempty"`
+	Version       uint32                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WelcomeHelloReq) Reset() {
+	*x = WelcomeHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WelcomeHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WelcomeHelloReq) ProtoMessage() {}
+
+func (x *WelcomeHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WelcomeHelloReq.ProtoReflect.Descriptor instead.
+func (*WelcomeHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WelcomeHelloReq) GetPublic() string {
+	if x != nil {
+		return x.Public
+	}
+	return ""
+}
+
+func (x *WelcomeHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type WelcomeVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WelcomeVerifyResp) Reset() {
+	*x = WelcomeVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WelcomeVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WelcomeVerifyResp) ProtoMessage() {}
+
+func (x *WelcomeVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WelcomeVerifyResp.ProtoReflect.Descriptor instead.
+func (*WelcomeVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{3}
+}
+
+type SendHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conv          string                 `protobuf:"bytes,1,opt,name=conv,proto3" json:"conv,omitempty"`
+	Mailboxes     []string               `protobuf:"bytes,2,rep,name=mailboxes,proto3" json:"mailboxes,omitempty"`
+	Msg           string                 `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	Recipients    []string               `protobuf:"bytes,4,rep,name=recipients,proto3" json:"recipients,omitempty"`
+	Sender        string                 `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
+	Version       uint32                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	Attachments   []string               `protobuf:"bytes,7,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendHelloReq) Reset() {
+	*x = SendHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendHelloReq) ProtoMessage() {}
+
+func (x *SendHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendHelloReq.ProtoReflect.Descriptor instead.
+func (*SendHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SendHelloReq) GetConv() string {
+	if x != nil {
+		return x.Conv
+	}
+	return ""
+}
+
+func (x *SendHelloReq) GetMailboxes() []string {
+	if x != nil {
+		return x.Mailboxes
+	}
+	return nil
+}
+
+func (x *SendHelloReq) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+func (x *SendHelloReq) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+func (x *SendHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *SendHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SendHelloReq) GetAttachments() []string {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+type SendVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conv          string                 `protobuf:"bytes,1,opt,name=conv,proto3" json:"conv,omitempty"`
+	Credential    string                 `protobuf:"bytes,2,opt,name=credential,proto3" json:"credential,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendVerifyResp) Reset() {
+	*x = SendVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendVerifyResp) ProtoMessage() {}
+
+func (x *SendVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendVerifyResp.ProtoReflect.Descriptor instead.
+func (*SendVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SendVerifyResp) GetConv() string {
+	if x != nil {
+		return x.Conv
+	}
+	return ""
+}
+
+func (x *SendVerifyResp) GetCredential() string {
+	if x != nil {
+		return x.Credential
+	}
+	return ""
+}
+
+type ViewHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         uint64                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Mailbox       string                 `protobuf:"bytes,2,opt,name=mailbox,proto3" json:"mailbox,omitempty"`
+	Sender        string                 `protobuf:"bytes,3,opt,name=sender,proto3" json:"sender,omitempty"`
+	Type          uint32                 `protobuf:"varint,4,opt,name=type,proto3" json:"type,omitempty"`
+	Version       uint32                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewHelloReq) Reset() {
+	*x = ViewHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewHelloReq) ProtoMessage() {}
+
+func (x *ViewHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewHelloReq.ProtoReflect.Descriptor instead.
+func (*ViewHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ViewHelloReq) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ViewHelloReq) GetMailbox() string {
+	if x != nil {
+		return x.Mailbox
+	}
+	return ""
+}
+
+func (x *ViewHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *ViewHelloReq) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *ViewHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ViewVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	List          string                 `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewVerifyResp) Reset() {
+	*x = ViewVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewVerifyResp) ProtoMessage() {}
+
+func (x *ViewVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewVerifyResp.ProtoReflect.Descriptor instead.
+func (*ViewVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ViewVerifyResp) GetList() string {
+	if x != nil {
+		return x.List
+	}
+	return ""
+}
+
+type DeleteHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mailbox       string                 `protobuf:"bytes,1,opt,name=mailbox,proto3" json:"mailbox,omitempty"`
+	Sender        string                 `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Type          uint32                 `protobuf:"varint,3,opt,name=type,proto3" json:"type,omitempty"`
+	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteHelloReq) Reset() {
+	*x = DeleteHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteHelloReq) ProtoMessage() {}
+
+func (x *DeleteHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteHelloReq.ProtoReflect.Descriptor instead.
+func (*DeleteHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteHelloReq) GetMailbox() string {
+	if x != nil {
+		return x.Mailbox
+	}
+	return ""
+}
+
+func (x *DeleteHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *DeleteHelloReq) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *DeleteHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteVerifyResp) Reset() {
+	*x = DeleteVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteVerifyResp) ProtoMessage() {}
+
+func (x *DeleteVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteVerifyResp.ProtoReflect.Descriptor instead.
+func (*DeleteVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{9}
+}
+
+type BatchSendHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*BatchSendItem       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Sender        string                 `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Version       uint32                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSendHelloReq) Reset() {
+	*x = BatchSendHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSendHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSendHelloReq) ProtoMessage() {}
+
+func (x *BatchSendHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSendHelloReq.ProtoReflect.Descriptor instead.
+func (*BatchSendHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BatchSendHelloReq) GetItems() []*BatchSendItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BatchSendHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *BatchSendHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type BatchSendItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attachments   []string               `protobuf:"bytes,1,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	Conv          string                 `protobuf:"bytes,2,opt,name=conv,proto3" json:"conv,omitempty"`
+	Mailboxes     []string               `protobuf:"bytes,3,rep,name=mailboxes,proto3" json:"mailboxes,omitempty"`
+	Msg           string                 `protobuf:"bytes,4,opt,name=msg,proto3" json:"msg,omitempty"`
+	Recipients    []string               `protobuf:"bytes,5,rep,name=recipients,proto3" json:"recipients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSendItem) Reset() {
+	*x = BatchSendItem{}
+	mi := &file_proto_ramble_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSendItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSendItem) ProtoMessage() {}
+
+func (x *BatchSendItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSendItem.ProtoReflect.Descriptor instead.
+func (*BatchSendItem) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BatchSendItem) GetAttachments() []string {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *BatchSendItem) GetConv() string {
+	if x != nil {
+		return x.Conv
+	}
+	return ""
+}
+
+func (x *BatchSendItem) GetMailboxes() []string {
+	if x != nil {
+		return x.Mailboxes
+	}
+	return nil
+}
+
+func (x *BatchSendItem) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+func (x *BatchSendItem) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+type BatchSendVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchSendResult     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSendVerifyResp) Reset() {
+	*x = BatchSendVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSendVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSendVerifyResp) ProtoMessage() {}
+
+func (x *BatchSendVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSendVerifyResp.ProtoReflect.Descriptor instead.
+func (*BatchSendVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchSendVerifyResp) GetResults() []*BatchSendResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchSendResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conv          string                 `protobuf:"bytes,1,opt,name=conv,proto3" json:"conv,omitempty"`
+	Credential    string                 `protobuf:"bytes,2,opt,name=credential,proto3" json:"credential,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSendResult) Reset() {
+	*x = BatchSendResult{}
+	mi := &file_proto_ramble_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSendResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSendResult) ProtoMessage() {}
+
+func (x *BatchSendResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSendResult.ProtoReflect.Descriptor instead.
+func (*BatchSendResult) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchSendResult) GetConv() string {
+	if x != nil {
+		return x.Conv
+	}
+	return ""
+}
+
+func (x *BatchSendResult) GetCredential() string {
+	if x != nil {
+		return x.Credential
+	}
+	return ""
+}
+
+func (x *BatchSendResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type RotateHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Public        string                 `protobuf:"bytes,1,opt,name=public,proto3" json:"public,omitempty"`
+	Sender        string                 `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Version       uint32                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateHelloReq) Reset() {
+	*x = RotateHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateHelloReq) ProtoMessage() {}
+
+func (x *RotateHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateHelloReq.ProtoReflect.Descriptor instead.
+func (*RotateHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RotateHelloReq) GetPublic() string {
+	if x != nil {
+		return x.Public
+	}
+	return ""
+}
+
+func (x *RotateHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *RotateHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RotateVerifyReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NewSig        string                 `protobuf:"bytes,1,opt,name=new_sig,json=newSig,proto3" json:"new_sig,omitempty"`
+	Sig           string                 `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+	Uuid          string                 `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateVerifyReq) Reset() {
+	*x = RotateVerifyReq{}
+	mi := &file_proto_ramble_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateVerifyReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateVerifyReq) ProtoMessage() {}
+
+func (x *RotateVerifyReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateVerifyReq.ProtoReflect.Descriptor instead.
+func (*RotateVerifyReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RotateVerifyReq) GetNewSig() string {
+	if x != nil {
+		return x.NewSig
+	}
+	return ""
+}
+
+func (x *RotateVerifyReq) GetSig() string {
+	if x != nil {
+		return x.Sig
+	}
+	return ""
+}
+
+func (x *RotateVerifyReq) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+type RotateVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateVerifyResp) Reset() {
+	*x = RotateVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateVerifyResp) ProtoMessage() {}
+
+func (x *RotateVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateVerifyResp.ProtoReflect.Descriptor instead.
+func (*RotateVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RotateVerifyResp) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+type LookupReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Version       uint32                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupReq) Reset() {
+	*x = LookupReq{}
+	mi := &file_proto_ramble_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupReq) ProtoMessage() {}
+
+func (x *LookupReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupReq.ProtoReflect.Descriptor instead.
+func (*LookupReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LookupReq) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *LookupReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type LookupResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupResp) Reset() {
+	*x = LookupResp{}
+	mi := &file_proto_ramble_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupResp) ProtoMessage() {}
+
+func (x *LookupResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupResp.ProtoReflect.Descriptor instead.
+func (*LookupResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LookupResp) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+type UploadHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sender        string                 `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Version       uint32                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadHelloReq) Reset() {
+	*x = UploadHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadHelloReq) ProtoMessage() {}
+
+func (x *UploadHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadHelloReq.ProtoReflect.Descriptor instead.
+func (*UploadHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UploadHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *UploadHelloReq) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *UploadHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type UploadVerifyResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChunkSize     int64                  `protobuf:"varint,1,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	Upload        string                 `protobuf:"bytes,2,opt,name=upload,proto3" json:"upload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadVerifyResp) Reset() {
+	*x = UploadVerifyResp{}
+	mi := &file_proto_ramble_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadVerifyResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadVerifyResp) ProtoMessage() {}
+
+func (x *UploadVerifyResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadVerifyResp.ProtoReflect.Descriptor instead.
+func (*UploadVerifyResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UploadVerifyResp) GetChunkSize() int64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *UploadVerifyResp) GetUpload() string {
+	if x != nil {
+		return x.Upload
+	}
+	return ""
+}
+
+type UploadChunkReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Upload        string                 `protobuf:"bytes,3,opt,name=upload,proto3" json:"upload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadChunkReq) Reset() {
+	*x = UploadChunkReq{}
+	mi := &file_proto_ramble_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadChunkReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadChunkReq) ProtoMessage() {}
+
+func (x *UploadChunkReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadChunkReq.ProtoReflect.Descriptor instead.
+func (*UploadChunkReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UploadChunkReq) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *UploadChunkReq) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *UploadChunkReq) GetUpload() string {
+	if x != nil {
+		return x.Upload
+	}
+	return ""
+}
+
+type UploadChunkResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadChunkResp) Reset() {
+	*x = UploadChunkResp{}
+	mi := &file_proto_ramble_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadChunkResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadChunkResp) ProtoMessage() {}
+
+func (x *UploadChunkResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadChunkResp.ProtoReflect.Descriptor instead.
+func (*UploadChunkResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UploadChunkResp) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *UploadChunkResp) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DownloadReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Length        int64                  `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadReq) Reset() {
+	*x = DownloadReq{}
+	mi := &file_proto_ramble_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadReq) ProtoMessage() {}
+
+func (x *DownloadReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadReq.ProtoReflect.Descriptor instead.
+func (*DownloadReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DownloadReq) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *DownloadReq) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *DownloadReq) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DownloadResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadResp) Reset() {
+	*x = DownloadResp{}
+	mi := &file_proto_ramble_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadResp) ProtoMessage() {}
+
+func (x *DownloadResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadResp.ProtoReflect.Descriptor instead.
+func (*DownloadResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DownloadResp) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DownloadResp) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type SubscribeHelloReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mailbox       string                 `protobuf:"bytes,1,opt,name=mailbox,proto3" json:"mailbox,omitempty"`
+	Sender        string                 `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Version       uint32                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeHelloReq) Reset() {
+	*x = SubscribeHelloReq{}
+	mi := &file_proto_ramble_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeHelloReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeHelloReq) ProtoMessage() {}
+
+func (x *SubscribeHelloReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeHelloReq.ProtoReflect.Descriptor instead.
+func (*SubscribeHelloReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SubscribeHelloReq) GetMailbox() string {
+	if x != nil {
+		return x.Mailbox
+	}
+	return ""
+}
+
+func (x *SubscribeHelloReq) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *SubscribeHelloReq) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Notice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conv          string                 `protobuf:"bytes,1,opt,name=conv,proto3" json:"conv,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notice) Reset() {
+	*x = Notice{}
+	mi := &file_proto_ramble_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notice) ProtoMessage() {}
+
+func (x *Notice) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notice.ProtoReflect.Descriptor instead.
+func (*Notice) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Notice) GetConv() string {
+	if x != nil {
+		return x.Conv
+	}
+	return ""
+}
+
+func (x *Notice) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type Frame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Body          []byte                 `protobuf:"bytes,1,opt,name=body,proto3" json:"body,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Id            uint64                 `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_proto_ramble_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Frame) GetBody() []byte {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Frame) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Frame) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type InfoReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoReq) Reset() {
+	*x = InfoReq{}
+	mi := &file_proto_ramble_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoReq) ProtoMessage() {}
+
+func (x *InfoReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoReq.ProtoReflect.Descriptor instead.
+func (*InfoReq) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{28}
+}
+
+type Limits struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	HandshakeTtl      int64                  `protobuf:"varint,1,opt,name=handshake_ttl,json=handshakeTtl,proto3" json:"handshake_ttl,omitempty"`
+	MaxCount          uint64                 `protobuf:"varint,2,opt,name=max_count,json=maxCount,proto3" json:"max_count,omitempty"`
+	MaxMsgSize        int64                  `protobuf:"varint,3,opt,name=max_msg_size,json=maxMsgSize,proto3" json:"max_msg_size,omitempty"`
+	ChunkSize         int64                  `protobuf:"varint,4,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	MaxAttachmentSize int64                  `protobuf:"varint,5,opt,name=max_attachment_size,json=maxAttachmentSize,proto3" json:"max_attachment_size,omitempty"`
+	MaxBatch          int64                  `protobuf:"varint,6,opt,name=max_batch,json=maxBatch,proto3" json:"max_batch,omitempty"`
+	SessionTtl        int64                  `protobuf:"varint,7,opt,name=session_ttl,json=sessionTtl,proto3" json:"session_ttl,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Limits) Reset() {
+	*x = Limits{}
+	mi := &file_proto_ramble_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Limits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Limits) ProtoMessage() {}
+
+func (x *Limits) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Limits.ProtoReflect.Descriptor instead.
+func (*Limits) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Limits) GetHandshakeTtl() int64 {
+	if x != nil {
+		return x.HandshakeTtl
+	}
+	return 0
+}
+
+func (x *Limits) GetMaxCount() uint64 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+func (x *Limits) GetMaxMsgSize() int64 {
+	if x != nil {
+		return x.MaxMsgSize
+	}
+	return 0
+}
+
+func (x *Limits) GetChunkSize() int64 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *Limits) GetMaxAttachmentSize() int64 {
+	if x != nil {
+		return x.MaxAttachmentSize
+	}
+	return 0
+}
+
+func (x *Limits) GetMaxBatch() int64 {
+	if x != nil {
+		return x.MaxBatch
+	}
+	return 0
+}
+
+func (x *Limits) GetSessionTtl() int64 {
+	if x != nil {
+		return x.SessionTtl
+	}
+	return 0
+}
+
+type InfoResp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Algorithms    []string               `protobuf:"bytes,1,rep,name=algorithms,proto3" json:"algorithms,omitempty"`
+	Limits        *Limits                `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	Requests      []string               `protobuf:"bytes,3,rep,name=requests,proto3" json:"requests,omitempty"`
+	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoResp) Reset() {
+	*x = InfoResp{}
+	mi := &file_proto_ramble_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResp) ProtoMessage() {}
+
+func (x *InfoResp) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ramble_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResp.ProtoReflect.Descriptor instead.
+func (*InfoResp) Descriptor() ([]byte, []int) {
+	return file_proto_ramble_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *InfoResp) GetAlgorithms() []string {
+	if x != nil {
+		return x.Algorithms
+	}
+	return nil
+}
+
+func (x *InfoResp) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *InfoResp) GetRequests() []string {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *InfoResp) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+var File_proto_ramble_proto protoreflect.FileDescriptor
+
+const file_proto_ramble_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/ramble.proto\x12\x06ramble\"9\n" +
+	"\rHelloResponse\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\tR\x05nonce\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\"5\n" +
+	"\rVerifyRequest\x12\x10\n" +
+	"\x03sig\x18\x01 \x01(\tR\x03sig\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\"C\n" +
+	"\x0fWelcomeHelloReq\x12\x16\n" +
+	"\x06public\x18\x01 \x01(\tR\x06public\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion\"\x13\n" +
+	"\x11WelcomeVerifyResp\"\xc6\x01\n" +
+	"\fSendHelloReq\x12\x12\n" +
+	"\x04conv\x18\x01 \x01(\tR\x04conv\x12\x1c\n" +
+	"\tmailboxes\x18\x02 \x03(\tR\tmailboxes\x12\x10\n" +
+	"\x03msg\x18\x03 \x01(\tR\x03msg\x12\x1e\n" +
+	"\n" +
+	"recipients\x18\x04 \x03(\tR\n" +
+	"recipients\x12\x16\n" +
+	"\x06sender\x18\x05 \x01(\tR\x06sender\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\rR\aversion\x12 \n" +
+	"\vattachments\x18\a \x03(\tR\vattachments\"D\n" +
+	"\x0eSendVerifyResp\x12\x12\n" +
+	"\x04conv\x18\x01 \x01(\tR\x04conv\x12\x1e\n" +
+	"\n" +
+	"credential\x18\x02 \x01(\tR\n" +
+	"credential\"\x84\x01\n" +
+	"\fViewHelloReq\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x04R\x05count\x12\x18\n" +
+	"\amailbox\x18\x02 \x01(\tR\amailbox\x12\x16\n" +
+	"\x06sender\x18\x03 \x01(\tR\x06sender\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\rR\x04type\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\rR\aversion\"$\n" +
+	"\x0eViewVerifyResp\x12\x12\n" +
+	"\x04list\x18\x01 \x01(\tR\x04list\"p\n" +
+	"\x0eDeleteHelloReq\x12\x18\n" +
+	"\amailbox\x18\x01 \x01(\tR\amailbox\x12\x16\n" +
+	"\x06sender\x18\x02 \x01(\tR\x06sender\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\rR\x04type\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\"\x12\n" +
+	"\x10DeleteVerifyResp\"r\n" +
+	"\x11BatchSendHelloReq\x12+\n" +
+	"\x05items\x18\x01 \x03(\v2\x15.ramble.BatchSendItemR\x05items\x12\x16\n" +
+	"\x06sender\x18\x02 \x01(\tR\x06sender\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\rR\aversion\"\x95\x01\n" +
+	"\rBatchSendItem\x12 \n" +
+	"\vattachments\x18\x01 \x03(\tR\vattachments\x12\x12\n" +
+	"\x04conv\x18\x02 \x01(\tR\x04conv\x12\x1c\n" +
+	"\tmailboxes\x18\x03 \x03(\tR\tmailboxes\x12\x10\n" +
+	"\x03msg\x18\x04 \x01(\tR\x03msg\x12\x1e\n" +
+	"\n" +
+	"recipients\x18\x05 \x03(\tR\n" +
+	"recipients\"H\n" +
+	"\x13BatchSendVerifyResp\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.ramble.BatchSendResultR\aresults\"[\n" +
+	"\x0fBatchSendResult\x12\x12\n" +
+	"\x04conv\x18\x01 \x01(\tR\x04conv\x12\x1e\n" +
+	"\n" +
+	"credential\x18\x02 \x01(\tR\n" +
+	"credential\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"Z\n" +
+	"\x0eRotateHelloReq\x12\x16\n" +
+	"\x06public\x18\x01 \x01(\tR\x06public\x12\x16\n" +
+	"\x06sender\x18\x02 \x01(\tR\x06sender\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\rR\aversion\"P\n" +
+	"\x0fRotateVerifyReq\x12\x17\n" +
+	"\anew_sig\x18\x01 \x01(\tR\x06newSig\x12\x10\n" +
+	"\x03sig\x18\x02 \x01(\tR\x03sig\x12\x12\n" +
+	"\x04uuid\x18\x03 \x01(\tR\x04uuid\"4\n" +
+	"\x10RotateVerifyResp\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\"G\n" +
+	"\tLookupReq\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion\".\n" +
+	"\n" +
+	"LookupResp\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\"V\n" +
+	"\x0eUploadHelloReq\x12\x16\n" +
+	"\x06sender\x18\x01 \x01(\tR\x06sender\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\rR\aversion\"I\n" +
+	"\x10UploadVerifyResp\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x01 \x01(\x03R\tchunkSize\x12\x16\n" +
+	"\x06upload\x18\x02 \x01(\tR\x06upload\"T\n" +
+	"\x0eUploadChunkReq\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06upload\x18\x03 \x01(\tR\x06upload\"=\n" +
+	"\x0fUploadChunkResp\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\"Q\n" +
+	"\vDownloadReq\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\x03R\x06length\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\"6\n" +
+	"\fDownloadResp\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\"_\n" +
+	"\x11SubscribeHelloReq\x12\x18\n" +
+	"\amailbox\x18\x01 \x01(\tR\amailbox\x12\x16\n" +
+	"\x06sender\x18\x02 \x01(\tR\x06sender\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\rR\aversion\".\n" +
+	"\x06Notice\x12\x12\n" +
+	"\x04conv\x18\x01 \x01(\tR\x04conv\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\"U\n" +
+	"\x05Frame\x12\x12\n" +
+	"\x04body\x18\x01 \x01(\fR\x04body\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\"\t\n" +
+	"\aInfoReq\"\xf9\x01\n" +
+	"\x06Limits\x12#\n" +
+	"\rhandshake_ttl\x18\x01 \x01(\x03R\fhandshakeTtl\x12\x1b\n" +
+	"\tmax_count\x18\x02 \x01(\x04R\bmaxCount\x12 \n" +
+	"\fmax_msg_size\x18\x03 \x01(\x03R\n" +
+	"maxMsgSize\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x04 \x01(\x03R\tchunkSize\x12.\n" +
+	"\x13max_attachment_size\x18\x05 \x01(\x03R\x11maxAttachmentSize\x12\x1b\n" +
+	"\tmax_batch\x18\x06 \x01(\x03R\bmaxBatch\x12\x1f\n" +
+	"\vsession_ttl\x18\a \x01(\x03R\n" +
+	"sessionTtl\"\x88\x01\n" +
+	"\bInfoResp\x12\x1e\n" +
+	"\n" +
+	"algorithms\x18\x01 \x03(\tR\n" +
+	"algorithms\x12&\n" +
+	"\x06limits\x18\x02 \x01(\v2\x0e.ramble.LimitsR\x06limits\x12\x1a\n" +
+	"\brequests\x18\x03 \x03(\tR\brequests\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion2\xfb\t\n" +
+	"\x06Ramble\x12>\n" +
+	"\fWelcomeHello\x12\x17.ramble.WelcomeHelloReq\x1a\x15.ramble.HelloResponse\x12A\n" +
+	"\rWelcomeVerify\x12\x15.ramble.VerifyRequest\x1a\x19.ramble.WelcomeVerifyResp\x128\n" +
+	"\tSendHello\x12\x14.ramble.SendHelloReq\x1a\x15.ramble.HelloResponse\x12;\n" +
+	"\n" +
+	"SendVerify\x12\x15.ramble.VerifyRequest\x1a\x16.ramble.SendVerifyResp\x128\n" +
+	"\tViewHello\x12\x14.ramble.ViewHelloReq\x1a\x15.ramble.HelloResponse\x12;\n" +
+	"\n" +
+	"ViewVerify\x12\x15.ramble.VerifyRequest\x1a\x16.ramble.ViewVerifyResp\x12<\n" +
+	"\vDeleteHello\x12\x16.ramble.DeleteHelloReq\x1a\x15.ramble.HelloResponse\x12?\n" +
+	"\fDeleteVerify\x12\x15.ramble.VerifyRequest\x1a\x18.ramble.DeleteVerifyResp\x12>\n" +
+	"\n" +
+	"BatchHello\x12\x19.ramble.BatchSendHelloReq\x1a\x15.ramble.HelloResponse\x12A\n" +
+	"\vBatchVerify\x12\x15.ramble.VerifyRequest\x1a\x1b.ramble.BatchSendVerifyResp\x12<\n" +
+	"\vRotateHello\x12\x16.ramble.RotateHelloReq\x1a\x15.ramble.HelloResponse\x12A\n" +
+	"\fRotateVerify\x12\x17.ramble.RotateVerifyReq\x1a\x18.ramble.RotateVerifyResp\x12/\n" +
+	"\x06Lookup\x12\x11.ramble.LookupReq\x1a\x12.ramble.LookupResp\x12<\n" +
+	"\vUploadHello\x12\x16.ramble.UploadHelloReq\x1a\x15.ramble.HelloResponse\x12?\n" +
+	"\fUploadVerify\x12\x15.ramble.VerifyRequest\x1a\x18.ramble.UploadVerifyResp\x12>\n" +
+	"\vUploadChunk\x12\x16.ramble.UploadChunkReq\x1a\x17.ramble.UploadChunkResp\x125\n" +
+	"\bDownload\x12\x13.ramble.DownloadReq\x1a\x14.ramble.DownloadResp\x12B\n" +
+	"\x0eSubscribeHello\x12\x19.ramble.SubscribeHelloReq\x1a\x15.ramble.HelloResponse\x12:\n" +
+	"\x0fSubscribeVerify\x12\x15.ramble.VerifyRequest\x1a\x0e.ramble.Notice0\x01\x12+\n" +
+	"\aSession\x12\r.ramble.Frame\x1a\r.ramble.Frame(\x010\x01\x12)\n" +
+	"\x04Info\x12\x0f.ramble.InfoReq\x1a\x10.ramble.InfoRespB!Z\x1fgithub.com/esote/ramble/pkg/rpcb\x06proto3"
+
+var (
+	file_proto_ramble_proto_rawDescOnce sync.Once
+	file_proto_ramble_proto_rawDescData []byte
+)
+
+func file_proto_ramble_proto_rawDescGZIP() []byte {
+	file_proto_ramble_proto_rawDescOnce.Do(func() {
+		file_proto_ramble_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_ramble_proto_rawDesc), len(file_proto_ramble_proto_rawDesc)))
+	})
+	return file_proto_ramble_proto_rawDescData
+}
+
+var file_proto_ramble_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
+var file_proto_ramble_proto_goTypes = []any{
+	(*HelloResponse)(nil),       // 0: ramble.HelloResponse
+	(*VerifyRequest)(nil),       // 1: ramble.VerifyRequest
+	(*WelcomeHelloReq)(nil),     // 2: ramble.WelcomeHelloReq
+	(*WelcomeVerifyResp)(nil),   // 3: ramble.WelcomeVerifyResp
+	(*SendHelloReq)(nil),        // 4: ramble.SendHelloReq
+	(*SendVerifyResp)(nil),      // 5: ramble.SendVerifyResp
+	(*ViewHelloReq)(nil),        // 6: ramble.ViewHelloReq
+	(*ViewVerifyResp)(nil),      // 7: ramble.ViewVerifyResp
+	(*DeleteHelloReq)(nil),      // 8: ramble.DeleteHelloReq
+	(*DeleteVerifyResp)(nil),    // 9: ramble.DeleteVerifyResp
+	(*BatchSendHelloReq)(nil),   // 10: ramble.BatchSendHelloReq
+	(*BatchSendItem)(nil),       // 11: ramble.BatchSendItem
+	(*BatchSendVerifyResp)(nil), // 12: ramble.BatchSendVerifyResp
+	(*BatchSendResult)(nil),     // 13: ramble.BatchSendResult
+	(*RotateHelloReq)(nil),      // 14: ramble.RotateHelloReq
+	(*RotateVerifyReq)(nil),     // 15: ramble.RotateVerifyReq
+	(*RotateVerifyResp)(nil),    // 16: ramble.RotateVerifyResp
+	(*LookupReq)(nil),           // 17: ramble.LookupReq
+	(*LookupResp)(nil),          // 18: ramble.LookupResp
+	(*UploadHelloReq)(nil),      // 19: ramble.UploadHelloReq
+	(*UploadVerifyResp)(nil),    // 20: ramble.UploadVerifyResp
+	(*UploadChunkReq)(nil),      // 21: ramble.UploadChunkReq
+	(*UploadChunkResp)(nil),     // 22: ramble.UploadChunkResp
+	(*DownloadReq)(nil),         // 23: ramble.DownloadReq
+	(*DownloadResp)(nil),        // 24: ramble.DownloadResp
+	(*SubscribeHelloReq)(nil),   // 25: ramble.SubscribeHelloReq
+	(*Notice)(nil),              // 26: ramble.Notice
+	(*Frame)(nil),               // 27: ramble.Frame
+	(*InfoReq)(nil),             // 28: ramble.InfoReq
+	(*Limits)(nil),              // 29: ramble.Limits
+	(*InfoResp)(nil),            // 30: ramble.InfoResp
+}
+var file_proto_ramble_proto_depIdxs = []int32{
+	11, // 0: ramble.BatchSendHelloReq.items:type_name -> ramble.BatchSendItem
+	13, // 1: ramble.BatchSendVerifyResp.results:type_name -> ramble.BatchSendResult
+	29, // 2: ramble.InfoResp.limits:type_name -> ramble.Limits
+	2,  // 3: ramble.Ramble.WelcomeHello:input_type -> ramble.WelcomeHelloReq
+	1,  // 4: ramble.Ramble.WelcomeVerify:input_type -> ramble.VerifyRequest
+	4,  // 5: ramble.Ramble.SendHello:input_type -> ramble.SendHelloReq
+	1,  // 6: ramble.Ramble.SendVerify:input_type -> ramble.VerifyRequest
+	6,  // 7: ramble.Ramble.ViewHello:input_type -> ramble.ViewHelloReq
+	1,  // 8: ramble.Ramble.ViewVerify:input_type -> ramble.VerifyRequest
+	8,  // 9: ramble.Ramble.DeleteHello:input_type -> ramble.DeleteHelloReq
+	1,  // 10: ramble.Ramble.DeleteVerify:input_type -> ramble.VerifyRequest
+	10, // 11: ramble.Ramble.BatchHello:input_type -> ramble.BatchSendHelloReq
+	1,  // 12: ramble.Ramble.BatchVerify:input_type -> ramble.VerifyRequest
+	14, // 13: ramble.Ramble.RotateHello:input_type -> ramble.RotateHelloReq
+	15, // 14: ramble.Ramble.RotateVerify:input_type -> ramble.RotateVerifyReq
+	17, // 15: ramble.Ramble.Lookup:input_type -> ramble.LookupReq
+	19, // 16: ramble.Ramble.UploadHello:input_type -> ramble.UploadHelloReq
+	1,  // 17: ramble.Ramble.UploadVerify:input_type -> ramble.VerifyRequest
+	21, // 18: ramble.Ramble.UploadChunk:input_type -> ramble.UploadChunkReq
+	23, // 19: ramble.Ramble.Download:input_type -> ramble.DownloadReq
+	25, // 20: ramble.Ramble.SubscribeHello:input_type -> ramble.SubscribeHelloReq
+	1,  // 21: ramble.Ramble.SubscribeVerify:input_type -> ramble.VerifyRequest
+	27, // 22: ramble.Ramble.Session:input_type -> ramble.Frame
+	28, // 23: ramble.Ramble.Info:input_type -> ramble.InfoReq
+	0,  // 24: ramble.Ramble.WelcomeHello:output_type -> ramble.HelloResponse
+	3,  // 25: ramble.Ramble.WelcomeVerify:output_type -> ramble.WelcomeVerifyResp
+	0,  // 26: ramble.Ramble.SendHello:output_type -> ramble.HelloResponse
+	5,  // 27: ramble.Ramble.SendVerify:output_type -> ramble.SendVerifyResp
+	0,  // 28: ramble.Ramble.ViewHello:output_type -> ramble.HelloResponse
+	7,  // 29: ramble.Ramble.ViewVerify:output_type -> ramble.ViewVerifyResp
+	0,  // 30: ramble.Ramble.DeleteHello:output_type -> ramble.HelloResponse
+	9,  // 31: ramble.Ramble.DeleteVerify:output_type -> ramble.DeleteVerifyResp
+	0,  // 32: ramble.Ramble.BatchHello:output_type -> ramble.HelloResponse
+	12, // 33: ramble.Ramble.BatchVerify:output_type -> ramble.BatchSendVerifyResp
+	0,  // 34: ramble.Ramble.RotateHello:output_type -> ramble.HelloResponse
+	16, // 35: ramble.Ramble.RotateVerify:output_type -> ramble.RotateVerifyResp
+	18, // 36: ramble.Ramble.Lookup:output_type -> ramble.LookupResp
+	0,  // 37: ramble.Ramble.UploadHello:output_type -> ramble.HelloResponse
+	20, // 38: ramble.Ramble.UploadVerify:output_type -> ramble.UploadVerifyResp
+	22, // 39: ramble.Ramble.UploadChunk:output_type -> ramble.UploadChunkResp
+	24, // 40: ramble.Ramble.Download:output_type -> ramble.DownloadResp
+	0,  // 41: ramble.Ramble.SubscribeHello:output_type -> ramble.HelloResponse
+	26, // 42: ramble.Ramble.SubscribeVerify:output_type -> ramble.Notice
+	27, // 43: ramble.Ramble.Session:output_type -> ramble.Frame
+	30, // 44: ramble.Ramble.Info:output_type -> ramble.InfoResp
+	24, // [24:45] is the sub-list for method output_type
+	3,  // [3:24] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_ramble_proto_init() }
+func file_proto_ramble_proto_init() {
+	if File_proto_ramble_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_ramble_proto_rawDesc), len(file_proto_ramble_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   31,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_ramble_proto_goTypes,
+		DependencyIndexes: file_proto_ramble_proto_depIdxs,
+		MessageInfos:      file_proto_ramble_proto_msgTypes,
+	}.Build()
+	File_proto_ramble_proto = out.File
+	file_proto_ramble_proto_goTypes = nil
+	file_proto_ramble_proto_depIdxs = nil
+}
@@ -0,0 +1,4 @@
+// Package rpc holds the generated gRPC service of the ramble protocol.
+package rpc
+
+//go:generate protoc -I ../.. --go_out=. --go_opt=module=github.com/esote/ramble/pkg/rpc --go-grpc_out=. --go-grpc_opt=module=github.com/esote/ramble/pkg/rpc proto/ramble.proto
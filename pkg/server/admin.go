@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// MessageInfo describes a stored message.
+type MessageInfo struct {
+	// Size of the armored message in bytes.
+	Size int
+
+	// UUID of the message.
+	UUID string
+}
+
+// Fingerprints lists the fingerprints of stored public keys.
+func (s *Server) Fingerprints() ([]string, error) {
+	return s.storedKeys(storePublic, false)
+}
+
+// Conversations lists the conversations linked to a fingerprint.
+func (s *Server) Conversations(fingerprint string) ([]string, error) {
+	fingerprint = strings.ToLower(fingerprint)
+
+	unlock := s.locks.rlock(fingerprint)
+	defer unlock()
+
+	convos, err := s.tconvos.IndexN(fingerprint, 0)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return convos, err
+}
+
+// Messages describes the messages of a conversation in the order they were
+// sent.
+func (s *Server) Messages(conv string) ([]MessageInfo, error) {
+	conv = strings.ToLower(conv)
+
+	unlock := s.locks.rlock(conv)
+	defer unlock()
+
+	msgs, err := s.tmsgs.IndexN(conv, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]MessageInfo, len(msgs))
+
+	for i, msg := range msgs {
+		b, err := s.msg.Read(msg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		infos[i] = MessageInfo{
+			Size: len(b),
+			UUID: msg,
+		}
+	}
+
+	return infos, nil
+}
+
+// Purge removes a user's public key, their list of conversations and any
+// pointer from their key to a rotated key. Conversations stay readable by
+// their other participants.
+func (s *Server) Purge(fingerprint string) error {
+	if !s.crypto.VerifyFingerprint(fingerprint) {
+		return errors.New("fingerprint is invalid")
+	}
+
+	fingerprint = strings.ToLower(fingerprint)
+
+	unlock := s.locks.lock(fingerprint)
+	defer unlock()
+
+	// Removed together, so a failure cannot leave a half-purged user.
+	tx := s.journal.Begin()
+	tx.Remove(storePublic, fingerprint)
+	tx.RemoveList(storeConvos, fingerprint)
+	tx.Remove(storeRotated, fingerprint)
+
+	err := tx.Commit()
+	s.keys.remove(fingerprint)
+
+	if err != nil {
+		return err
+	}
+
+	s.forget(fingerprint)
+
+	return nil
+}
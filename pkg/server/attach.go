@@ -0,0 +1,717 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/internal/uuid"
+)
+
+const (
+	// Size of attachment chunks, each of which is stored as its own value.
+	chunkSize = 256 << 10
+
+	hashHexLen = 2 * sha256.Size
+)
+
+// An upload in progress.
+type upload struct {
+	chunk    int
+	size     int64
+	received int64
+	hash     hash.Hash
+	time     time.Time
+
+	// restored is set for uploads loaded from their records, whose chunks
+	// are read again to continue them.
+	restored bool
+
+	// done is set once the upload is finished or abandoned.
+	done bool
+	mu   sync.Mutex
+}
+
+// Stored description of an attachment. The chunk size is kept so attachments
+// remain readable if it changes.
+type manifest struct {
+	Chunk  int    `json:"chunk"`
+	Size   int64  `json:"size"`
+	Upload string `json:"upload"`
+}
+
+// Stored record of an upload, so that uploads and unreferenced attachments
+// survive restarts. It is removed once the upload is abandoned, or its
+// attachment is referenced or collected.
+type uploadRecord struct {
+	Chunk int `json:"chunk"`
+
+	// Hash of the attachment, set once the upload is finished.
+	Hash string `json:"hash,omitempty"`
+
+	// Removed is set once the attachment is collected but its chunks may
+	// remain.
+	Removed bool `json:"removed,omitempty"`
+
+	Size int64 `json:"size"`
+}
+
+func chunkKey(upload string, i int64) string {
+	return fmt.Sprintf("%s.%d", upload, i)
+}
+
+// UploadHello processes the hello handshake step.
+func (s *Server) UploadHello(req *ramble.UploadHelloReq) (*ramble.UploadHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if !s.crypto.VerifyFingerprint(req.Sender) {
+		return nil, errors.New("sender fingerprint is invalid")
+	}
+
+	req.Sender = strings.ToLower(req.Sender)
+
+	if req.Size <= 0 {
+		return nil, errors.New("attachment size <= 0")
+	}
+
+	if s.maxAttach > 0 && req.Size > s.maxAttach {
+		return nil, fmt.Errorf("attachment exceeds %d bytes", s.maxAttach)
+	}
+
+	resp, err := s.newHelloResponse(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.UploadHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// UploadVerify processes the verify handshake step, starting the upload.
+func (s *Server) UploadVerify(req *ramble.UploadVerifyReq) (*ramble.UploadVerifyResp, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hello, ok := meta.request.(*ramble.UploadHelloReq)
+
+	if !ok {
+		return nil, errors.New("request was not UploadHelloReq")
+	}
+
+	public, err := s.publicKey(hello.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.UUID()
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(uploadRecord{
+		Chunk: s.chunk,
+		Size:  hello.Size,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.uploadRecs.Write(id, b); err != nil {
+		return nil, err
+	}
+
+	s.attachMu.Lock()
+	s.uploads[id] = &upload{
+		chunk: s.chunk,
+		size:  hello.Size,
+		hash:  sha256.New(),
+		time:  time.Now().UTC(),
+	}
+	s.attachMu.Unlock()
+
+	return &ramble.UploadVerifyResp{
+		ChunkSize: s.chunk,
+		Upload:    id,
+	}, nil
+}
+
+// UploadChunk stores the next chunk of an upload. The upload ID authorizes the
+// request, so no handshake is needed.
+func (s *Server) UploadChunk(req *ramble.UploadChunkReq) (*ramble.UploadChunkResp, error) {
+	s.attachMu.Lock()
+	u, ok := s.uploads[req.Upload]
+	s.attachMu.Unlock()
+
+	if !ok {
+		return nil, errors.New("no upload with ID")
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.done {
+		return nil, errors.New("no upload with ID")
+	}
+
+	u.time = time.Now().UTC()
+
+	if u.restored {
+		if err := s.restoreUpload(req.Upload, u); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &ramble.UploadChunkResp{
+		Offset: u.received,
+	}
+
+	// The upload may have stopped once all its data was stored, such as
+	// by a restart.
+	if u.received == u.size {
+		var err error
+		resp.Hash, err = s.finishUpload(req.Upload, u)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
+
+	if len(req.Data) == 0 {
+		return resp, nil
+	}
+
+	if req.Offset != u.received {
+		return nil, fmt.Errorf("chunk offset is not %d", u.received)
+	}
+
+	n := int64(len(req.Data))
+
+	if n > u.size-u.received {
+		return nil, errors.New("chunk exceeds attachment size")
+	}
+
+	if n != int64(u.chunk) && u.received+n != u.size {
+		return nil, fmt.Errorf("chunk is not %d bytes", u.chunk)
+	}
+
+	key := chunkKey(req.Upload, u.received/int64(u.chunk))
+
+	if err := s.chunks.Write(key, req.Data); err != nil {
+		return nil, err
+	}
+
+	_, _ = u.hash.Write(req.Data)
+	u.received += n
+	resp.Offset = u.received
+
+	if u.received == u.size {
+		var err error
+		resp.Hash, err = s.finishUpload(req.Upload, u)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// Hashes the stored chunks of an upload loaded from its record, so that it can
+// be continued.
+func (s *Server) restoreUpload(id string, u *upload) error {
+	u.hash = sha256.New()
+	u.received = 0
+
+	for i := int64(0); u.received < u.size; i++ {
+		b, err := s.chunks.Read(chunkKey(id, i))
+
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		_, _ = u.hash.Write(b)
+		u.received += int64(len(b))
+	}
+
+	u.restored = false
+
+	return nil
+}
+
+// Stores the manifest of a finished upload, or drops the upload if an identical
+// attachment is already stored. The attachment is collected unless referenced
+// before the next prune.
+func (s *Server) finishUpload(id string, u *upload) (string, error) {
+	sum := hex.EncodeToString(u.hash.Sum(nil))
+
+	s.attachMu.Lock()
+	defer s.attachMu.Unlock()
+
+	unlock := s.locks.lock(sum)
+	defer unlock()
+
+	_, err := s.attach.Read(sum)
+
+	if err == nil {
+		err = s.removeChunks(id, u.received, u.chunk)
+
+		if err == nil {
+			err = removeExisting(s.uploadRecs.Remove, id)
+		}
+	} else if os.IsNotExist(err) {
+		err = s.storeManifest(id, u, sum)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	u.done = true
+	delete(s.uploads, id)
+	s.unref[sum] = time.Now().UTC()
+
+	return sum, nil
+}
+
+// Stores the manifest of a finished upload with its record, so the attachment
+// is collected after a restart if it is not referenced.
+func (s *Server) storeManifest(id string, u *upload, sum string) error {
+	m, err := json.Marshal(manifest{
+		Chunk:  u.chunk,
+		Size:   u.size,
+		Upload: id,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	rec, err := json.Marshal(uploadRecord{
+		Chunk: u.chunk,
+		Hash:  sum,
+		Size:  u.size,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	tx := s.journal.Begin()
+	tx.Write(storeAttach, sum, m)
+	tx.Write(storeUploads, id, rec)
+
+	return tx.Commit()
+}
+
+// Removes the chunks holding the first n bytes of an upload.
+func (s *Server) removeChunks(id string, n int64, chunk int) error {
+	for i := int64(0); i*int64(chunk) < n; i++ {
+		err := s.chunks.Remove(chunkKey(id, i))
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks the attachment hashes of a send request exist, normalizing them.
+func (s *Server) checkAttachments(hashes []string) error {
+	for i, h := range hashes {
+		if len(h) != hashHexLen || !reHex.MatchString(h) {
+			return fmt.Errorf("attachment hash index=%d is invalid", i)
+		}
+
+		hashes[i] = strings.ToLower(h)
+
+		if _, err := s.attach.Read(hashes[i]); os.IsNotExist(err) {
+			return fmt.Errorf("attachment index=%d does not exist", i)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Records a message's references to attachments in a transaction, so they are
+// not collected once it commits. The attachments must be locked.
+func (s *Server) referenceAttachments(tx *store.Tx, msg string, hashes []string) error {
+	for _, h := range hashes {
+		b, err := s.attach.Read(h)
+
+		if os.IsNotExist(err) {
+			return errors.New("attachment expired")
+		} else if err != nil {
+			return err
+		}
+
+		var m manifest
+
+		if err = json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+
+		tx.Insert(storeTattach, h, msg)
+		tx.Remove(storeUploads, m.Upload)
+	}
+
+	return nil
+}
+
+// Removes an attachment if no stored message references it. Returns whether
+// the attachment is still referenced. Must be called with s.attachMu held.
+func (s *Server) collectAttachment(h string) (bool, error) {
+	unlock := s.locks.lock(h)
+	defer unlock()
+
+	refs, err := s.tattach.IndexN(h, 0)
+
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	for _, msg := range refs {
+		if _, err = s.msg.Read(msg); err == nil {
+			return true, nil
+		} else if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	b, err := s.attach.Read(h)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var m manifest
+
+	if err = json.Unmarshal(b, &m); err != nil {
+		return false, err
+	}
+
+	rec, err := json.Marshal(uploadRecord{
+		Chunk:   m.Chunk,
+		Removed: true,
+		Size:    m.Size,
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	// The record keeps the chunks to be removed if removing them is
+	// interrupted.
+	tx := s.journal.Begin()
+	tx.Remove(storeAttach, h)
+	tx.RemoveList(storeTattach, h)
+	tx.Write(storeUploads, m.Upload, rec)
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return false, s.removeUpload(m.Upload, m.Size, m.Chunk)
+}
+
+// Removes the chunks holding the first n bytes of an upload, then its record.
+func (s *Server) removeUpload(id string, n int64, chunk int) error {
+	if err := s.removeChunks(id, n, chunk); err != nil {
+		return err
+	}
+
+	return removeExisting(s.uploadRecs.Remove, id)
+}
+
+// Loads the records of uploads, so unfinished uploads can be continued and
+// unreferenced attachments are collected.
+func (s *Server) loadUploads(ids []string) error {
+	now := time.Now().UTC()
+
+	for _, id := range ids {
+		b, err := s.uploadRecs.Read(id)
+
+		if err != nil {
+			return err
+		}
+
+		var rec uploadRecord
+
+		if err = json.Unmarshal(b, &rec); err != nil {
+			return err
+		}
+
+		switch {
+		case rec.Hash != "":
+			s.unref[rec.Hash] = now
+		case rec.Removed:
+			err = s.removeUpload(id, rec.Size, rec.Chunk)
+		default:
+			s.uploads[id] = &upload{
+				chunk:    rec.Chunk,
+				size:     rec.Size,
+				time:     now,
+				restored: true,
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Loads the referenced attachments, so they are collected once no stored
+// message references them.
+func (s *Server) loadAttachments() error {
+	hashes, err := s.storedKeys(storeTattach, true)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for _, h := range hashes {
+		s.unref[h] = now
+	}
+
+	return nil
+}
+
+// Abandons uploads and collects unreferenced attachments older than s.dur.
+func (s *Server) pruneAttachments(now time.Time) {
+	s.attachMu.Lock()
+	uploads := make(map[string]*upload, len(s.uploads))
+
+	for id, u := range s.uploads {
+		uploads[id] = u
+	}
+
+	s.attachMu.Unlock()
+
+	for id, u := range uploads {
+		u.mu.Lock()
+
+		if u.done || now.Sub(u.time) <= s.dur {
+			u.mu.Unlock()
+			continue
+		}
+
+		u.done = true
+		u.mu.Unlock()
+
+		s.attachMu.Lock()
+		delete(s.uploads, id)
+		s.attachMu.Unlock()
+
+		// The chunks stored before a restart are not counted.
+		n := u.received
+
+		if u.restored {
+			n = u.size
+		}
+
+		if err := s.removeUpload(id, n, u.chunk); err != nil {
+			log.Printf("abandon upload %s: %v\n", id, err)
+		}
+	}
+
+	s.attachMu.Lock()
+	defer s.attachMu.Unlock()
+
+	for h, t := range s.unref {
+		if now.Sub(t) <= s.dur {
+			continue
+		}
+
+		referenced, err := s.collectAttachment(h)
+
+		if err != nil {
+			log.Printf("collect attachment %s: %v\n", h, err)
+			continue
+		}
+
+		// Referenced attachments are checked again later, so they are
+		// collected once their messages are gone.
+		if referenced {
+			s.unref[h] = now
+		} else {
+			delete(s.unref, h)
+		}
+	}
+}
+
+// Attachment reads a stored attachment. It implements io.ReadSeeker and
+// io.ReaderAt.
+type Attachment struct {
+	chunks store.Blobs
+	m      manifest
+	off    int64
+}
+
+// OpenAttachment opens the attachment with a hash.
+func (s *Server) OpenAttachment(h string) (*Attachment, error) {
+	if len(h) != hashHexLen || !reHex.MatchString(h) {
+		return nil, errors.New("attachment hash is invalid")
+	}
+
+	b, err := s.attach.Read(strings.ToLower(h))
+
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Attachment{
+		chunks: s.chunks,
+	}
+
+	if err = json.Unmarshal(b, &a.m); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Size of the attachment in bytes.
+func (a *Attachment) Size() int64 {
+	return a.m.Size
+}
+
+// ReadAt reads len(p) bytes from offset off.
+func (a *Attachment) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	chunk := int64(a.m.Chunk)
+
+	for n < len(p) && off < a.m.Size {
+		i := off / chunk
+		b, err := a.chunks.Read(chunkKey(a.m.Upload, i))
+
+		if err != nil {
+			return n, err
+		}
+
+		start := off - i*chunk
+
+		if start >= int64(len(b)) {
+			return n, errors.New("attachment chunk truncated")
+		}
+
+		c := copy(p[n:], b[start:])
+		n += c
+		off += int64(c)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Read reads from the current offset.
+func (a *Attachment) Read(p []byte) (int, error) {
+	n, err := a.ReadAt(p, a.off)
+	a.off += int64(n)
+
+	return n, err
+}
+
+// Seek sets the offset of the next read.
+func (a *Attachment) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		break
+	case io.SeekCurrent:
+		offset += a.off
+	case io.SeekEnd:
+		offset += a.m.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	a.off = offset
+
+	return offset, nil
+}
+
+// Download reads part of an attachment.
+func (s *Server) Download(req *ramble.DownloadReq) (*ramble.DownloadResp, error) {
+	a, err := s.OpenAttachment(req.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Offset < 0 {
+		return nil, errors.New("negative offset")
+	}
+
+	length := req.Length
+
+	if length <= 0 || length > int64(a.m.Chunk) {
+		length = int64(a.m.Chunk)
+	}
+
+	if rest := a.m.Size - req.Offset; rest < length {
+		length = rest
+	}
+
+	if length < 0 {
+		length = 0
+	}
+
+	data := make([]byte, length)
+	n, err := a.ReadAt(data, req.Offset)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &ramble.DownloadResp{
+		Data: data[:n],
+		Size: a.m.Size,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/esote/ramble"
+)
+
+// A checked batch send request. Items which failed their checks are kept so
+// their errors can be reported in the verify response.
+type batchSend struct {
+	sender string
+	items  []batchItem
+}
+
+type batchItem struct {
+	req *ramble.SendHelloReq
+	err error
+}
+
+// BatchSendHello processes the hello handshake step.
+func (s *Server) BatchSendHello(req *ramble.BatchSendHelloReq) (*ramble.BatchSendHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if len(req.Items) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	if s.maxBatch > 0 && len(req.Items) > s.maxBatch {
+		return nil, fmt.Errorf("batch exceeds %d items", s.maxBatch)
+	}
+
+	if !s.crypto.VerifyFingerprint(req.Sender) {
+		return nil, errors.New("sender fingerprint is invalid")
+	}
+
+	batch := &batchSend{
+		sender: strings.ToLower(req.Sender),
+		items:  make([]batchItem, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		send := &ramble.SendHelloReq{
+			Attachments:  item.Attachments,
+			Conversation: item.Conversation,
+			Mailboxes:    item.Mailboxes,
+			Message:      item.Message,
+			Recipients:   item.Recipients,
+			Sender:       batch.sender,
+		}
+
+		batch.items[i] = batchItem{
+			req: send,
+			err: s.checkSend(send),
+		}
+	}
+
+	resp, err := s.newHelloResponse(batch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.BatchSendHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// BatchSendVerify processes the verify handshake step. Items are sent in order,
+// and an item failing does not stop the others from being sent.
+func (s *Server) BatchSendVerify(req *ramble.BatchSendVerifyReq) (*ramble.BatchSendVerifyResp, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	batch, ok := meta.request.(*batchSend)
+
+	if !ok {
+		return nil, errors.New("request was not BatchSendHelloReq")
+	}
+
+	public, err := s.publicKey(batch.sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	results := make([]ramble.BatchSendResult, len(batch.items))
+
+	for i, item := range batch.items {
+		// Errors are not passed on to the client, as for other
+		// requests.
+		if item.err != nil {
+			log.Printf("batch item %d: %v\n", i, item.err)
+			results[i].Error = "bad request"
+			continue
+		}
+
+		resp, err := s.send(item.req, "")
+
+		if err != nil {
+			log.Printf("batch item %d: %v\n", i, err)
+			results[i].Error = "message not stored"
+			continue
+		}
+
+		results[i].Conversation = resp.Conversation
+		results[i].Credential = resp.Credential
+	}
+
+	return &ramble.BatchSendVerifyResp{
+		Results: results,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/internal/store"
+)
+
+const credentialLen = 32
+
+// Generates a credential for sealed senders of a conversation, writing its hash
+// in a transaction. Only the credential's hash is stored.
+func newCredential(tx *store.Tx, conv string) (string, error) {
+	b := make([]byte, credentialLen)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	credential := hex.EncodeToString(b)
+	sum := sha256.Sum256([]byte(credential))
+
+	tx.Write(storeCreds, conv, sum[:])
+
+	return credential, nil
+}
+
+// Checks that a sealed sender may send. Anyone may start a conversation with
+// mailboxes, as it links no key to them. Otherwise the conversation must exist
+// and credential must authorize it. The conversation and its members must be
+// locked.
+func (s *Server) checkSealed(hello *ramble.SendHelloReq, credential string, created bool) error {
+	if created {
+		if len(hello.Mailboxes) == 0 {
+			return errors.New("sealed sender requires an existing" +
+				" conversation")
+		}
+
+		return nil
+	}
+
+	if err := s.checkCredential(hello.Conversation, credential); err != nil {
+		return err
+	}
+
+	return s.checkMembers(hello)
+}
+
+// Checks that credential authorizes sealed senders of a conversation, which
+// must be locked.
+func (s *Server) checkCredential(conv, credential string) error {
+	stored, err := s.creds.Read(conv)
+
+	if os.IsNotExist(err) {
+		return errors.New("conversation has no credential")
+	} else if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256([]byte(credential))
+
+	if subtle.ConstantTimeCompare(sum[:], stored) != 1 {
+		return errors.New("conversation credential invalid")
+	}
+
+	return nil
+}
+
+// Checks that the recipients or mailboxes of a sealed send are already members
+// of its conversation, so the shared credential cannot add anyone to it.
+func (s *Server) checkMembers(hello *ramble.SendHelloReq) error {
+	for i, r := range hello.Recipients {
+		ok, err := listHas(s.tconvos, r, hello.Conversation)
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("recipient index=%d is not a member of"+
+				" the conversation", i)
+		}
+	}
+
+	for i, m := range hello.Mailboxes {
+		ok, err := listHas(s.tmailbox, m, hello.Conversation)
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("mailbox index=%d is not a member of"+
+				" the conversation", i)
+		}
+	}
+
+	return nil
+}
+
+// Checks whether a list holds a value.
+func listHas(lists store.Lists, key, value string) (bool, error) {
+	values, err := lists.IndexN(key, 0)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
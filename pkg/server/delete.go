@@ -5,17 +5,14 @@ import (
 	"strings"
 
 	"github.com/esote/ramble"
-	"github.com/esote/ramble/internal/pgp"
 )
 
 // DeleteHello processes the hello handshake step.
 func (s *Server) DeleteHello(req *ramble.DeleteHelloReq) (*ramble.DeleteHelloResp, error) {
-	if !pgp.VerifyHexFingerprint(req.Sender) {
-		return nil, errors.New("sender fingerprint is invalid")
+	if err := s.checkDelete(req); err != nil {
+		return nil, err
 	}
 
-	req.Sender = strings.ToLower(req.Sender)
-
 	resp, err := s.newHelloResponse(req)
 
 	if err != nil {
@@ -41,7 +38,31 @@ func (s *Server) DeleteVerify(req *ramble.DeleteVerifyReq) (*ramble.DeleteVerify
 		return nil, errors.New("request was not DeleteHelloReq")
 	}
 
-	public, err := s.public.Read(hello.Sender)
+	if hello.Mailbox != "" {
+		err = verifyMailboxSig(hello.Mailbox, req.Signature,
+			meta.nonce)
+
+		if err != nil {
+			return nil, err
+		}
+
+		unlock := s.locks.lock(hello.Mailbox)
+		err = s.tmailbox.Remove(hello.Mailbox)
+
+		if err == nil {
+			s.forget(mailboxKey(hello.Mailbox))
+		}
+
+		unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return new(ramble.DeleteVerifyResp), nil
+	}
+
+	public, err := s.publicKey(hello.Sender)
 
 	if err != nil {
 		return nil, err
@@ -51,22 +72,67 @@ func (s *Server) DeleteVerify(req *ramble.DeleteVerifyReq) (*ramble.DeleteVerify
 		return nil, err
 	}
 
+	if err = s.delete(hello); err != nil {
+		return nil, err
+	}
+
+	return new(ramble.DeleteVerifyResp), nil
+}
+
+// Checks a delete request, normalizing its fingerprint or mailbox address.
+func (s *Server) checkDelete(req *ramble.DeleteHelloReq) error {
+	if err := checkVersion(req.Version); err != nil {
+		return err
+	}
+
+	if req.Mailbox != "" {
+		if req.Sender != "" {
+			return errors.New("both sender and mailbox given")
+		}
+
+		if req.Type != ramble.DeleteConversations {
+			return errors.New("mailboxes only hold conversations")
+		}
+
+		if !verifyMailbox(req.Mailbox) {
+			return errors.New("mailbox address is invalid")
+		}
+
+		req.Mailbox = strings.ToLower(req.Mailbox)
+	} else if !s.crypto.VerifyFingerprint(req.Sender) {
+		return errors.New("sender fingerprint is invalid")
+	}
+
+	req.Sender = strings.ToLower(req.Sender)
+
+	return nil
+}
+
+// Deletes the data of an authorized sender.
+func (s *Server) delete(hello *ramble.DeleteHelloReq) error {
+	var err error
+
+	unlock := s.locks.lock(hello.Sender)
+	defer unlock()
+
 	switch hello.Type {
 	case ramble.DeleteAll:
 		err = s.public.Remove(hello.Sender)
+		s.keys.remove(hello.Sender)
 
 		if err == nil {
-			err = s.tconvos.Splay.Remove(hello.Sender)
+			err = s.tconvos.Remove(hello.Sender)
 		}
 	case ramble.DeletePublic:
 		err = s.public.Remove(hello.Sender)
+		s.keys.remove(hello.Sender)
 	case ramble.DeleteConversations:
-		err = s.tconvos.Splay.Remove(hello.Sender)
+		err = s.tconvos.Remove(hello.Sender)
 	}
 
-	if err != nil {
-		return nil, err
+	if err == nil && hello.Type != ramble.DeletePublic {
+		s.forget(hello.Sender)
 	}
 
-	return new(ramble.DeleteVerifyResp), nil
+	return err
 }
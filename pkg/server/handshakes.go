@@ -0,0 +1,151 @@
+package server
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Number of handshake shards, so that handshakes with different UUIDs rarely
+// contend for the same lock.
+const handshakeShards = 64
+
+type verifyMeta struct {
+	nonce   string
+	request interface{}
+	time    time.Time
+}
+
+// Active hello-verify handshakes by UUID, split into shards each with their
+// own lock. Each shard keeps its handshakes in a heap by time, so pruning only
+// visits expired handshakes.
+type handshakes struct {
+	shards []handshakeShard
+}
+
+type handshakeShard struct {
+	active map[string]verifyMeta
+	expiry expiryHeap
+	mu     sync.Mutex
+}
+
+type expiry struct {
+	time time.Time
+	uuid string
+}
+
+// Min-heap of handshake times. Entries of finished handshakes are left in the
+// heap until they expire.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap) Less(i, j int) bool {
+	return h[i].time.Before(h[j].time)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	*h = append(*h, x.(expiry))
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+
+	return x
+}
+
+// Creates a handshake store with n shards.
+func newHandshakes(n int) *handshakes {
+	h := &handshakes{
+		shards: make([]handshakeShard, n),
+	}
+
+	for i := range h.shards {
+		h.shards[i].active = make(map[string]verifyMeta)
+	}
+
+	return h
+}
+
+func (h *handshakes) shard(uuid string) *handshakeShard {
+	return &h.shards[shardOf(uuid, len(h.shards))]
+}
+
+// Adds a handshake, returning false if one with the UUID already exists.
+func (h *handshakes) add(uuid string, m verifyMeta) bool {
+	sh := h.shard(uuid)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, ok := sh.active[uuid]; ok {
+		return false
+	}
+
+	sh.active[uuid] = m
+	heap.Push(&sh.expiry, expiry{
+		time: m.time,
+		uuid: uuid,
+	})
+
+	return true
+}
+
+// Removes and returns a handshake.
+func (h *handshakes) take(uuid string) (verifyMeta, bool) {
+	sh := h.shard(uuid)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	m, ok := sh.active[uuid]
+
+	if ok {
+		delete(sh.active, uuid)
+	}
+
+	return m, ok
+}
+
+// Removes handshakes older than dur, locking one shard at a time.
+func (h *handshakes) prune(now time.Time, dur time.Duration) {
+	for i := range h.shards {
+		sh := &h.shards[i]
+
+		sh.mu.Lock()
+
+		for len(sh.expiry) != 0 && now.Sub(sh.expiry[0].time) > dur {
+			e := heap.Pop(&sh.expiry).(expiry)
+
+			if m, ok := sh.active[e.uuid]; ok && m.time.Equal(e.time) {
+				delete(sh.active, e.uuid)
+			}
+		}
+
+		sh.mu.Unlock()
+	}
+}
+
+// Number of active handshakes.
+func (h *handshakes) len() int {
+	n := 0
+
+	for i := range h.shards {
+		sh := &h.shards[i]
+
+		sh.mu.Lock()
+		n += len(sh.active)
+		sh.mu.Unlock()
+	}
+
+	return n
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/esote/ramble"
+)
+
+// Requests are the request types performed by Server, sorted. Transports need
+// not serve them all, and report those they do to Info.
+var Requests = []string{
+	"batch",
+	"delete",
+	"download",
+	"lookup",
+	"rotate",
+	"send",
+	"session",
+	"subscribe",
+	"upload",
+	"view",
+	"welcome",
+}
+
+// ServedRequests gets the Requests for which served is true, sorted, so that
+// each transport reports its request types in the same terms.
+func ServedRequests(served func(request string) bool) []string {
+	var requests []string
+
+	for _, r := range Requests {
+		if served(r) {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests
+}
+
+// VersionError is returned for requests of a protocol version the server does
+// not speak.
+type VersionError struct {
+	// Version of the request.
+	Version uint32
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("protocol version %d unsupported, server speaks"+
+		" version %d", e.Version, ramble.Version)
+}
+
+// Requests which do not specify a version are assumed to speak the current
+// version.
+func checkVersion(version uint32) error {
+	if version != 0 && version != ramble.Version {
+		return &VersionError{Version: version}
+	}
+
+	return nil
+}
+
+// Info describes the protocol spoken by the server and the limits it enforces,
+// given the request types served by the caller's transport.
+func (s *Server) Info(requests []string) *ramble.InfoResp {
+	return &ramble.InfoResp{
+		Algorithms: s.crypto.Algorithms(),
+		Limits: ramble.Limits{
+			ChunkSize:         s.chunk,
+			HandshakeTTL:      int64(s.dur.Seconds()),
+			MaxAttachmentSize: s.maxAttach,
+			MaxBatch:          s.maxBatch,
+			MaxCount:          s.maxCount,
+			MaxMessageSize:    s.maxMsg,
+			SessionTTL:        int64(s.sessDur.Seconds()),
+		},
+		Requests: append([]string(nil), requests...),
+		Version:  ramble.Version,
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+
+	"github.com/esote/ramble/internal/pgp"
+)
+
+// Default number of parsed public keys cached.
+const keyCacheSize = 1024
+
+// Cache of parsed public keys by fingerprint, evicting the least recently used
+// key once full. Removals advance the epoch, so that a key parsed before a
+// removal is not added after it.
+type keyCache struct {
+	max     int
+	epoch   uint64
+	entries map[string]*list.Element
+	lru     *list.List
+	mu      sync.Mutex
+}
+
+type keyEntry struct {
+	fingerprint string
+	key         pgp.Key
+}
+
+// Creates a key cache holding up to max keys, which is disabled if max <= 0.
+func newKeyCache(max int) *keyCache {
+	return &keyCache{
+		max:     max,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Gets a cached key. The epoch is passed to add when the key is missing.
+func (c *keyCache) get(fingerprint string) (key pgp.Key, epoch uint64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[fingerprint]
+
+	if !ok {
+		return nil, c.epoch, false
+	}
+
+	c.lru.MoveToFront(e)
+
+	return e.Value.(*keyEntry).key, c.epoch, true
+}
+
+// Adds a key read at epoch, unless a key was removed since.
+func (c *keyCache) add(fingerprint string, key pgp.Key, epoch uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.max <= 0 || epoch != c.epoch {
+		return
+	}
+
+	if e, ok := c.entries[fingerprint]; ok {
+		e.Value.(*keyEntry).key = key
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[fingerprint] = c.lru.PushFront(&keyEntry{
+		fingerprint: fingerprint,
+		key:         key,
+	})
+
+	if c.lru.Len() > c.max {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*keyEntry).fingerprint)
+	}
+}
+
+// Removes a key, which must be done after its stored key changes.
+func (c *keyCache) remove(fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+
+	if e, ok := c.entries[fingerprint]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, fingerprint)
+	}
+}
+
+// Gets the parsed public key stored for a fingerprint.
+func (s *Server) publicKey(fingerprint string) (pgp.Key, error) {
+	key, epoch, ok := s.keys.get(fingerprint)
+
+	if ok {
+		return key, nil
+	}
+
+	unlock := s.locks.rlock(fingerprint)
+	public, err := s.public.Read(fingerprint)
+	unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = s.crypto.ParseKey(bytes.NewReader(public))
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys.add(fingerprint, key, epoch)
+
+	return key, nil
+}
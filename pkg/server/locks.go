@@ -0,0 +1,81 @@
+package server
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// Number of stripes keys are locked by.
+const lockStripes = 256
+
+// Locks stored data by key, such as a fingerprint, conversation or mailbox.
+// Keys share a fixed number of striped locks, which are not reentrant: a caller
+// holding locks must not lock keys again until it unlocks them.
+type keyLocks struct {
+	stripes []sync.RWMutex
+}
+
+func newKeyLocks(n int) *keyLocks {
+	return &keyLocks{
+		stripes: make([]sync.RWMutex, n),
+	}
+}
+
+// Gets the shard of n a key belongs to.
+func shardOf(key string, n int) int {
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(key))
+
+	return int(f.Sum32() % uint32(n))
+}
+
+// Gets the distinct stripes of keys in ascending order, so that callers
+// locking several keys cannot deadlock.
+func (l *keyLocks) order(keys []string) []int {
+	var stripes []int
+	seen := make(map[int]bool, len(keys))
+
+	for _, key := range keys {
+		i := shardOf(key, len(l.stripes))
+
+		if !seen[i] {
+			seen[i] = true
+			stripes = append(stripes, i)
+		}
+	}
+
+	sort.Ints(stripes)
+
+	return stripes
+}
+
+// Locks keys for writing, returning a function which unlocks them.
+func (l *keyLocks) lock(keys ...string) func() {
+	stripes := l.order(keys)
+
+	for _, i := range stripes {
+		l.stripes[i].Lock()
+	}
+
+	return func() {
+		for _, i := range stripes {
+			l.stripes[i].Unlock()
+		}
+	}
+}
+
+// Locks keys for reading, returning a function which unlocks them.
+func (l *keyLocks) rlock(keys ...string) func() {
+	stripes := l.order(keys)
+
+	for _, i := range stripes {
+		l.stripes[i].RLock()
+	}
+
+	return func() {
+		for _, i := range stripes {
+			l.stripes[i].RUnlock()
+		}
+	}
+}
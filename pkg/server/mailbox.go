@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/esote/ramble"
+)
+
+const mailboxHexLen = 2 * sha256.Size
+
+// Does rough checks to see if a blinded mailbox address is valid.
+func verifyMailbox(address string) bool {
+	return len(address) == mailboxHexLen && reHex.MatchString(address)
+}
+
+// Checks that sig proves possession of the blinded mailbox at address, as made
+// by ramble.MailboxSign over nonce.
+func verifyMailboxSig(address, sig, nonce string) error {
+	b, err := hex.DecodeString(sig)
+
+	if err != nil || len(b) != ed25519.PublicKeySize+ed25519.SignatureSize {
+		return errors.New("mailbox signature invalid")
+	}
+
+	public := ed25519.PublicKey(b[:ed25519.PublicKeySize])
+	sum := sha256.Sum256(public)
+	a := []byte(hex.EncodeToString(sum[:]))
+
+	if subtle.ConstantTimeCompare(a, []byte(address)) != 1 {
+		return errors.New("mailbox key does not match address")
+	}
+
+	if !ed25519.Verify(public, []byte(nonce), b[ed25519.PublicKeySize:]) {
+		return errors.New("mailbox signature invalid")
+	}
+
+	return nil
+}
+
+// Views the conversations of a blinded mailbox, writing them to w. The list is
+// not encrypted as the mailbox cannot be linked to a key, so it is padded with
+// newlines to hide how many conversations it holds.
+func (s *Server) viewMailbox(hello *ramble.ViewHelloReq, sig, nonce string, w io.Writer) error {
+	if err := verifyMailboxSig(hello.Mailbox, sig, nonce); err != nil {
+		return err
+	}
+
+	unlock := s.locks.rlock(hello.Mailbox)
+	convos, err := s.tmailbox.IndexN(hello.Mailbox, hello.Count)
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	for _, convo := range convos {
+		buf.WriteString(convo)
+		buf.Write([]byte{'\n'})
+	}
+
+	buf.Write(bytes.Repeat([]byte{'\n'}, padLen(buf.Len(), s.buckets)))
+
+	_, err = buf.WriteTo(w)
+
+	return err
+}
+
+// Gets the length of padding filling n bytes to the smallest bucket they fit in,
+// or to a multiple of the largest bucket.
+func padLen(n int, buckets []int) int {
+	if len(buckets) == 0 {
+		return 0
+	}
+
+	for _, b := range buckets {
+		if n <= b {
+			return b - n
+		}
+	}
+
+	largest := buckets[len(buckets)-1]
+
+	return (n+largest-1)/largest*largest - n
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/esote/ramble"
+)
+
+// RotateHello processes the hello handshake step.
+func (s *Server) RotateHello(req *ramble.RotateHelloReq) (*ramble.RotateHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if !s.crypto.VerifyFingerprint(req.Sender) {
+		return nil, errors.New("sender fingerprint is invalid")
+	}
+
+	req.Sender = strings.ToLower(req.Sender)
+
+	public := strings.NewReader(req.Public)
+
+	if ok, err := s.crypto.VerifyPublic(public); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("input not a public key")
+	}
+
+	key, err := s.crypto.ParseKey(strings.NewReader(req.Public))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = key.VerifyUsable(time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.newHelloResponse(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.RotateHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// RotateVerify processes the verify handshake step.
+func (s *Server) RotateVerify(req *ramble.RotateVerifyReq) (*ramble.RotateVerifyResp, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hello, ok := meta.request.(*ramble.RotateHelloReq)
+
+	if !ok {
+		return nil, errors.New("request was not RotateHelloReq")
+	}
+
+	old, err := s.publicKey(hello.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(old, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	key, err := s.crypto.ParseKey(strings.NewReader(hello.Public))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(key, req.NewSignature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	public := strings.NewReader(hello.Public)
+	fingerprint, err := s.crypto.Fingerprint(public)
+
+	if err != nil {
+		return nil, errors.New("unable to get public key fingerprint")
+	}
+
+	if fingerprint == hello.Sender {
+		return nil, errors.New("new key is the same as the old key")
+	}
+
+	// Both keys are locked, so no conversation is linked to the old key
+	// after its conversations are moved.
+	unlock := s.locks.lock(hello.Sender, fingerprint)
+	defer unlock()
+
+	convos, err := s.tconvos.IndexN(hello.Sender, 0)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// The key and everything indexed by it move together, so a failure
+	// cannot leave both keys owning part of the conversations.
+	tx := s.journal.Begin()
+	tx.Write(storePublic, fingerprint, []byte(hello.Public))
+
+	for _, convo := range convos {
+		tx.Insert(storeConvos, fingerprint, convo)
+	}
+
+	// The new key may itself have been rotated away from in the past.
+	// Remove its pointer so lookups cannot loop.
+	tx.Remove(storeRotated, fingerprint)
+	tx.Write(storeRotated, hello.Sender, []byte(fingerprint))
+	tx.RemoveList(storeConvos, hello.Sender)
+	tx.Remove(storePublic, hello.Sender)
+
+	err = tx.Commit()
+	s.keys.remove(fingerprint)
+	s.keys.remove(hello.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.moveSubscriptions(hello.Sender, fingerprint)
+
+	return &ramble.RotateVerifyResp{
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// Lookup follows rotation pointers to find the current fingerprint of a key.
+// It is unauthenticated, so anyone knowing a fingerprint may learn whether it
+// is registered and which key replaced it.
+func (s *Server) Lookup(req *ramble.LookupReq) (*ramble.LookupResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if !s.crypto.VerifyFingerprint(req.Fingerprint) {
+		return nil, errors.New("fingerprint is invalid")
+	}
+
+	fingerprint := strings.ToLower(req.Fingerprint)
+
+	for {
+		unlock := s.locks.rlock(fingerprint)
+		next, err := s.rotated.Read(fingerprint)
+		unlock()
+
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		fingerprint = string(next)
+	}
+
+	unlock := s.locks.rlock(fingerprint)
+	_, err := s.public.Read(fingerprint)
+	unlock()
+
+	if err != nil {
+		return nil, errors.New("fingerprint not found")
+	}
+
+	return &ramble.LookupResp{
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// Calls remove on key, ignoring the error if key does not exist.
+func removeExisting(remove func(string) error, key string) error {
+	if err := remove(key); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
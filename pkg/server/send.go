@@ -3,6 +3,7 @@ package server
 import (
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
@@ -15,8 +16,72 @@ var reHex = regexp.MustCompile("^[a-fA-F0-9]+$")
 
 // SendHello processes the hello handshake step.
 func (s *Server) SendHello(req *ramble.SendHelloReq) (*ramble.SendHelloResp, error) {
-	if len(req.Recipients) == 0 {
-		return nil, errors.New("empty recipient list")
+	if err := s.checkSend(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.newHelloResponse(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.SendHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// SendVerify processes the verify handshake step.
+func (s *Server) SendVerify(req *ramble.SendVerifyReq) (*ramble.SendVerifyResp, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hello, ok := meta.request.(*ramble.SendHelloReq)
+
+	if !ok {
+		return nil, errors.New("request was not SendHelloReq")
+	}
+
+	// Sealed senders are authorized by send, as their credential must be
+	// checked under the conversation's lock.
+	if hello.Sender != "" {
+		public, err := s.publicKey(hello.Sender)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.verifyReqSig(public, req.Signature, meta.nonce)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.send(hello, req.Signature)
+}
+
+// Checks a send request, normalizing its fingerprints and mailbox addresses.
+// Generates a conversation UUID if none is given.
+func (s *Server) checkSend(req *ramble.SendHelloReq) error {
+	if err := checkVersion(req.Version); err != nil {
+		return err
+	}
+
+	if len(req.Recipients) == 0 && len(req.Mailboxes) == 0 {
+		return errors.New("empty recipient list")
+	}
+
+	if len(req.Recipients) != 0 && len(req.Mailboxes) != 0 {
+		return errors.New("both recipients and mailboxes given")
+	}
+
+	if req.Sender == "" && req.Conversation == "" && len(req.Mailboxes) == 0 {
+		return errors.New("sealed sender requires a conversation or" +
+			" mailboxes")
 	}
 
 	if req.Conversation == "" {
@@ -24,102 +89,245 @@ func (s *Server) SendHello(req *ramble.SendHelloReq) (*ramble.SendHelloResp, err
 		req.Conversation, err = uuid.UUID()
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if len(req.Conversation) != uuid.LenUUID ||
 		!reHex.MatchString(req.Conversation) {
-		return nil, errors.New("conversation UUID invalid")
+		return errors.New("conversation UUID invalid")
 	}
 
-	if !pgp.VerifyHexFingerprint(req.Sender) {
-		return nil, errors.New("sender fingerprint is invalid")
+	if req.Sender != "" && !s.crypto.VerifyFingerprint(req.Sender) {
+		return errors.New("sender fingerprint is invalid")
 	}
 
 	req.Sender = strings.ToLower(req.Sender)
 
 	for i, r := range req.Recipients {
-		if !pgp.VerifyHexFingerprint(r) {
-			return nil, fmt.Errorf("recipient fingerprint index=%d"+
+		if !s.crypto.VerifyFingerprint(r) {
+			return fmt.Errorf("recipient fingerprint index=%d"+
 				" is invalid", i)
 		}
 
 		req.Recipients[i] = strings.ToLower(r)
 	}
 
+	for i, m := range req.Mailboxes {
+		if !verifyMailbox(m) {
+			return fmt.Errorf("mailbox address index=%d"+
+				" is invalid", i)
+		}
+
+		req.Mailboxes[i] = strings.ToLower(m)
+	}
+
+	if s.maxMsg > 0 && len(req.Message) > s.maxMsg {
+		return fmt.Errorf("message exceeds %d bytes", s.maxMsg)
+	}
+
 	msg := strings.NewReader(req.Message)
 
-	if ok, err := pgp.VerifyEncryptedArmored(msg); err != nil {
-		return nil, err
+	if ok, err := s.crypto.VerifyEncrypted(msg); err != nil {
+		return err
 	} else if !ok {
-		return nil, errors.New("message is not encrypted and armored")
+		return errors.New("message is not encrypted and armored")
 	}
 
-	resp, err := s.newHelloResponse(req)
+	if err := s.checkAttachments(req.Attachments); err != nil {
+		return err
+	}
+
+	msg = strings.NewReader(req.Message)
+	ids, err := s.crypto.Recipients(msg)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	ret := ramble.SendHelloResp(*resp)
+	if len(req.Mailboxes) != 0 {
+		return verifyHidden(len(req.Mailboxes), ids)
+	}
 
-	return &ret, nil
+	return s.verifyRecipients(req.Recipients, ids)
 }
 
-// SendVerify processes the verify handshake step.
-func (s *Server) SendVerify(req *ramble.SendVerifyReq) (*ramble.SendVerifyResp, error) {
-	meta, err := s.verifyReq(req.UUID)
+// Stores a checked send request whose sender is verified. A sealed sender is
+// authorized by credential.
+func (s *Server) send(hello *ramble.SendHelloReq, credential string) (*ramble.SendVerifyResp, error) {
+	msg, err := uuid.UUID()
 
 	if err != nil {
 		return nil, err
 	}
 
-	hello, ok := meta.request.(*ramble.SendHelloReq)
+	// The conversation and its participants are locked, so the conversation
+	// is created once and sends cannot race with deletes or rotations. The
+	// attachments are locked so they are not collected before the message
+	// is stored.
+	locked := append([]string{hello.Conversation}, hello.Recipients...)
+	locked = append(locked, hello.Mailboxes...)
+	locked = append(locked, hello.Attachments...)
 
-	if !ok {
-		return nil, errors.New("request was not SendHelloReq")
+	if hello.Sender != "" {
+		locked = append(locked, hello.Sender)
 	}
 
-	public, err := s.public.Read(hello.Sender)
+	unlock := s.locks.lock(locked...)
+	defer unlock()
 
-	if err != nil {
+	prev, err := s.tmsgs.IndexN(hello.Conversation, 0)
+	created := os.IsNotExist(err)
+
+	if err != nil && !created {
 		return nil, err
 	}
 
-	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
-		return nil, err
+	if hello.Sender == "" {
+		if err = s.checkSealed(hello, credential, created); err != nil {
+			return nil, err
+		}
 	}
 
-	msg, err := uuid.UUID()
+	// The message is written before it is indexed, and the writes are
+	// committed together so a failure cannot leave some of them behind.
+	tx := s.journal.Begin()
+	tx.Write(storeMessages, msg, []byte(hello.Message))
+	tx.Insert(storeMsgs, hello.Conversation, msg)
+
+	err = s.referenceAttachments(tx, msg, hello.Attachments)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err = s.tmsgs.Insert(hello.Conversation, msg); err != nil {
-		return nil, err
+	// Conversations sent to mailboxes are not linked to the sender.
+	for _, m := range hello.Mailboxes {
+		tx.Insert(storeMailboxes, m, hello.Conversation)
 	}
 
-	if err = s.msg.Write(msg, []byte(hello.Message)); err != nil {
-		return nil, err
+	if len(hello.Mailboxes) == 0 && hello.Sender != "" {
+		tx.Insert(storeConvos, hello.Sender, hello.Conversation)
 	}
 
-	err = s.tconvos.InsertUnique(hello.Sender, hello.Conversation)
+	for _, r := range hello.Recipients {
+		tx.Insert(storeConvos, r, hello.Conversation)
+	}
 
-	if err != nil {
-		return nil, err
+	resp := &ramble.SendVerifyResp{
+		Conversation: hello.Conversation,
 	}
 
-	for _, r := range hello.Recipients {
-		err = s.tconvos.InsertUnique(r, hello.Conversation)
+	if created {
+		resp.Credential, err = newCredential(tx, hello.Conversation)
 
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return &ramble.SendVerifyResp{
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// The participants named by this message are now members, and are
+	// notified along with the others.
+	var keys []string
+
+	if hello.Sender != "" && len(hello.Mailboxes) == 0 {
+		keys = append(keys, hello.Sender)
+	}
+
+	keys = append(keys, hello.Recipients...)
+
+	for _, m := range hello.Mailboxes {
+		keys = append(keys, mailboxKey(m))
+	}
+
+	s.notify(hello.Conversation, keys, ramble.Notice{
 		Conversation: hello.Conversation,
-	}, nil
+		Sequence:     uint64(len(prev)),
+	})
+
+	return resp, nil
+}
+
+// Checks that a message has one encrypted session key for each recipient, and
+// that each key ID which is not hidden belongs to a distinct recipient.
+func (s *Server) verifyRecipients(recipients []string, ids []pgp.KeyID) error {
+	if len(ids) != len(recipients) {
+		return errors.New("message recipients do not match recipient list")
+	}
+
+	var owners map[pgp.KeyID]int
+	matched := make([]bool, len(recipients))
+
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+
+		if owners == nil {
+			var err error
+			owners, err = s.keyOwners(recipients)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		i, ok := owners[id]
+
+		if !ok {
+			return fmt.Errorf("message encrypted to key id=%s not"+
+				" in recipient list", id)
+		}
+
+		if matched[i] {
+			return fmt.Errorf("recipient index=%d has more than one"+
+				" encrypted session key", i)
+		}
+
+		matched[i] = true
+	}
+
+	return nil
+}
+
+// Checks that a message sent to mailboxes has one encrypted session key for each
+// mailbox, none of which reveal their key ID.
+func verifyHidden(mailboxes int, ids []pgp.KeyID) error {
+	if len(ids) != mailboxes {
+		return errors.New("message recipients do not match mailboxes")
+	}
+
+	for _, id := range ids {
+		if id != "" {
+			return errors.New("message to mailboxes reveals key id")
+		}
+	}
+
+	return nil
+}
+
+// Maps the key IDs of each stored recipient key to the recipient's index.
+// Recipients without a stored key cannot be matched.
+func (s *Server) keyOwners(recipients []string) (map[pgp.KeyID]int, error) {
+	owners := make(map[pgp.KeyID]int)
+
+	for i, r := range recipients {
+		public, err := s.publicKey(r)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, id := range public.KeyIDs() {
+			owners[id] = i
+		}
+	}
+
+	return owners, nil
 }
@@ -2,79 +2,443 @@
 package server
 
 import (
-	"bytes"
 	"errors"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/esote/ramble"
 	"github.com/esote/ramble/internal/pgp"
+	"github.com/esote/ramble/internal/store"
 	"github.com/esote/ramble/internal/uuid"
-	"github.com/esote/util/splay"
-	"github.com/esote/util/table"
 )
 
-type verifyMeta struct {
-	nonce   string
-	request interface{}
-	time    time.Time
+// Storage backends.
+const (
+	// BackendBolt keeps data in the bbolt database BoltFile in Dir.
+	BackendBolt = "bolt"
+
+	// BackendFiles keeps data in splayed files in Dir.
+	BackendFiles = "files"
+)
+
+// BoltFile is the name of the database used by BackendBolt.
+const BoltFile = "ramble.db"
+
+// JournalDir is the directory holding the records of transactions not yet
+// applied.
+const JournalDir = storeJournal
+
+// Names of the stores kept by a server.
+const (
+	storeAttach    = "s_attachments"
+	storeChunks    = "s_attachment_chunks"
+	storeConvos    = "s_table_convos"
+	storeCreds     = "s_credentials"
+	storeJournal   = "s_journal"
+	storeMailboxes = "s_table_mailboxes"
+	storeMessages  = "s_messages"
+	storeMsgs      = "s_table_msgs"
+	storePublic    = "s_public_keys"
+	storeRotated   = "s_rotated_keys"
+	storeTattach   = "s_table_attachments"
+	storeUploads   = "s_uploads"
+)
+
+// BlobStores and ListStores name the stores kept by a server, for tools which
+// work on its data directly. List values are ListValueLen bytes long. Lists
+// are kept as blobs when data is encrypted at rest.
+var (
+	BlobStores = []string{storeAttach, storeChunks, storeCreds,
+		storeMessages, storePublic, storeRotated, storeUploads}
+	ListStores = []string{storeConvos, storeMailboxes, storeMsgs,
+		storeTattach}
+)
+
+// ListValueLen is the length of list values, which are UUIDs.
+const ListValueLen = uuid.LenUUID
+
+// Config configures a Server.
+type Config struct {
+	// Backend is the storage backend, BackendFiles or BackendBolt.
+	// Defaults to BackendFiles.
+	Backend string
+
+	// Crypto backend used to verify and encrypt to users' keys. Defaults
+	// to OpenPGP.
+	Crypto pgp.Crypto
+
+	// Dir is the directory storage files are kept in. Defaults to the
+	// working directory. It is locked against use by other servers until
+	// the server is closed.
+	Dir string
+
+	// Dur is the duration that hello-verify handshakes may remain active.
+	Dur time.Duration
+
+	// KeyCacheSize is the number of parsed public keys kept in memory.
+	// Defaults to 1024, and a negative size disables the cache.
+	KeyCacheSize int
+
+	// MasterKeys enables encryption at rest of stored keys and values when
+	// set. The first key seals new values, the others only open values
+	// written under them until "ramble-admin reseal" rewrites those under
+	// the first key. Storage written without master keys cannot be read
+	// with them, and vice versa.
+	MasterKeys [][]byte
+
+	// MaxAttachmentSize is the largest attachment in bytes accepted by
+	// upload requests, or 0 for no limit.
+	MaxAttachmentSize int64
+
+	// MaxBatch is the largest number of items accepted by batch send
+	// requests, or 0 for no limit.
+	MaxBatch int
+
+	// MaxCount is the largest count accepted by view requests, or 0 for no
+	// limit.
+	MaxCount uint64
+
+	// MaxMessageSize is the largest armored message in bytes accepted by
+	// send requests, or 0 for no limit.
+	MaxMessageSize int
+
+	// PaddingBuckets are the sizes view responses are padded to when using
+	// the default Crypto backend, for example pgp.DefaultBuckets. Blinded
+	// mailbox lists are padded to them with any backend. No padding is
+	// added if empty.
+	PaddingBuckets []int
+
+	// ReadOnly opens the storage without changing it, for tools which
+	// inspect the data. Unfinished transactions are not applied, nothing is
+	// pruned and writes fail. Read-only servers may share Dir with each
+	// other, but not with a server which writes.
+	ReadOnly bool
+
+	// SessionDur is the longest a session stays open, after which it is
+	// closed. Defaults to Dur.
+	SessionDur time.Duration
 }
 
 // Server is a ramble server tasked with storing public keys, encrypted
 // messages, and hello-verify handshakes.
 type Server struct {
-	dur time.Duration
+	buckets []int
+	crypto  pgp.Crypto
+	dur     time.Duration
+	sessDur time.Duration
+
+	chunk     int
+	maxAttach int64
+	maxBatch  int
+	maxCount  uint64
+	maxMsg    int
+
+	active *handshakes
+	keys   *keyCache
+	locks  *keyLocks
+
+	// Journal making writes of sends, rotations and attachments atomic.
+	journal *store.Journal
+
+	attach   store.Blobs
+	chunks   store.Blobs
+	creds    store.Blobs
+	msg      store.Blobs
+	public   store.Blobs
+	rotated  store.Blobs
+	tattach  store.Lists
+	tconvos  store.Lists
+	tmailbox store.Lists
+	tmsgs    store.Lists
+
+	uploadRecs store.Blobs
+
+	backend store.Backend
+	sealer  *store.Sealer
+
+	// Unlocks the data directory.
+	unlock func() error
+
+	done chan struct{}
+
+	// Subscriptions by key, and the subscribed members of each
+	// conversation.
+	members map[string]map[string]struct{}
+	subs    map[string]*subscriber
+
+	// Uploads in progress, and finished attachments not yet referenced by
+	// a message, loaded from upload records at startup.
+	uploads map[string]*upload
+	unref   map[string]time.Time
+
+	attachMu sync.Mutex
+	subMu    sync.Mutex
+}
 
-	active map[string]verifyMeta
+// NewServer creates a new server.
+func NewServer(config *Config) (server *Server, err error) {
+	server = &Server{
+		buckets:   config.PaddingBuckets,
+		crypto:    config.Crypto,
+		dur:       config.Dur,
+		sessDur:   config.SessionDur,
+		chunk:     chunkSize,
+		maxAttach: config.MaxAttachmentSize,
+		maxBatch:  config.MaxBatch,
+		maxCount:  config.MaxCount,
+		maxMsg:    config.MaxMessageSize,
+		done:      make(chan struct{}),
+		active:    newHandshakes(handshakeShards),
+		locks:     newKeyLocks(lockStripes),
+		members:   make(map[string]map[string]struct{}),
+		subs:      make(map[string]*subscriber),
+		uploads:   make(map[string]*upload),
+		unref:     make(map[string]time.Time),
+	}
 
-	msg     *splay.Splay
-	public  *splay.Splay
-	tconvos *table.Table
-	tmsgs   *table.Table
+	if server.sessDur == 0 {
+		server.sessDur = server.dur
+	}
 
-	mu sync.Mutex
-}
+	if config.KeyCacheSize == 0 {
+		server.keys = newKeyCache(keyCacheSize)
+	} else {
+		server.keys = newKeyCache(config.KeyCacheSize)
+	}
 
-// NewServer creates a new server. dur is the duration that hello-verify
-// handshakes may remain active.
-func NewServer(dur time.Duration) (server *Server, err error) {
-	server = &Server{
-		dur:    dur,
-		active: make(map[string]verifyMeta),
+	if server.crypto == nil {
+		server.crypto = pgp.OpenPGP{
+			Buckets: config.PaddingBuckets,
+		}
 	}
 
-	server.msg, err = splay.NewSplay("s_messages", 2)
+	var sealer *store.Sealer
 
-	if err != nil {
+	if len(config.MasterKeys) != 0 {
+		if sealer, err = store.NewSealer(config.MasterKeys); err != nil {
+			return
+		}
+
+		server.sealer = sealer
+	}
+
+	path := func(name string) string {
+		return filepath.Join(config.Dir, name)
+	}
+
+	if config.Backend != "" && config.Backend != BackendFiles &&
+		config.Backend != BackendBolt {
+		return nil, errors.New("unknown storage backend")
+	}
+
+	dir := config.Dir
+
+	if dir == "" {
+		dir = "."
+	}
+
+	if !config.ReadOnly {
+		if err = os.MkdirAll(dir, 0700); err != nil {
+			return
+		}
+	}
+
+	if server.unlock, err = store.LockDir(dir, config.ReadOnly); err != nil {
 		return
 	}
 
-	server.public, err = splay.NewSplay("s_public_keys", 2)
+	defer func() {
+		if err != nil {
+			_ = server.unlock()
+		}
+	}()
+
+	var db *store.Bolt
+
+	switch {
+	case config.Backend == BackendBolt && config.ReadOnly:
+		db, err = store.OpenBoltReadOnly(path(BoltFile))
+	case config.Backend == BackendBolt:
+		db, err = store.OpenBolt(path(BoltFile))
+	case config.ReadOnly:
+		server.backend = store.NewReadOnlyFiles(config.Dir)
+	default:
+		server.backend = store.NewFiles(config.Dir)
+	}
 
 	if err != nil {
 		return
 	}
 
-	server.tconvos, err = table.NewTable("s_table_convos", 2, uuid.LenUUID)
+	if db != nil {
+		server.backend = db
+	}
 
-	if err != nil {
+	defer func() {
+		if err != nil {
+			_ = server.backend.Close()
+		}
+	}()
+
+	blobs := func(name string) (store.Blobs, error) {
+		s, err := server.backend.Blobs(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if sealer == nil {
+			return s, nil
+		}
+
+		return sealer.Blobs(s), nil
+	}
+
+	// Sealed values are longer than UUIDs, so sealed lists are kept as
+	// whole blobs rather than tables.
+	lists := func(name string) (store.Lists, error) {
+		if sealer != nil {
+			b, err := blobs(name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return store.NewLists(b, uuid.LenUUID), nil
+		}
+
+		return server.backend.Lists(name, uuid.LenUUID)
+	}
+
+	if server.attach, err = blobs(storeAttach); err != nil {
+		return
+	}
+
+	if server.chunks, err = blobs(storeChunks); err != nil {
+		return
+	}
+
+	if server.creds, err = blobs(storeCreds); err != nil {
+		return
+	}
+
+	if server.msg, err = blobs(storeMessages); err != nil {
+		return
+	}
+
+	if server.public, err = blobs(storePublic); err != nil {
+		return
+	}
+
+	if server.rotated, err = blobs(storeRotated); err != nil {
+		return
+	}
+
+	if server.tattach, err = lists(storeTattach); err != nil {
+		return
+	}
+
+	if server.tconvos, err = lists(storeConvos); err != nil {
+		return
+	}
+
+	if server.tmailbox, err = lists(storeMailboxes); err != nil {
+		return
+	}
+
+	if server.tmsgs, err = lists(storeMsgs); err != nil {
+		return
+	}
+
+	if server.uploadRecs, err = blobs(storeUploads); err != nil {
+		return
+	}
+
+	// The database applies a send in one transaction, so it needs no log.
+	if db != nil {
+		server.journal = store.NewBoltJournal(db)
+	} else {
+		journal := store.NewFileLog(path(storeJournal))
+
+		if sealer != nil {
+			journal = sealer.Log(journal)
+		}
+
+		server.journal = store.NewJournal(journal)
+	}
+
+	for name, b := range map[string]store.Blobs{
+		storeAttach:   server.attach,
+		storeCreds:    server.creds,
+		storeMessages: server.msg,
+		storePublic:   server.public,
+		storeRotated:  server.rotated,
+		storeUploads:  server.uploadRecs,
+	} {
+		server.journal.RegisterBlobs(name, b)
+	}
+
+	for name, l := range map[string]store.Lists{
+		storeConvos:    server.tconvos,
+		storeMailboxes: server.tmailbox,
+		storeMsgs:      server.tmsgs,
+		storeTattach:   server.tattach,
+	} {
+		server.journal.RegisterLists(name, l)
+	}
+
+	if config.ReadOnly {
+		return
+	}
+
+	if err = server.journal.Recover(); err != nil {
 		return
 	}
 
-	server.tmsgs, err = table.NewTable("s_table_msgs", 2, uuid.LenUUID)
+	uploads, err := server.storedKeys(storeUploads, false)
 
 	if err != nil {
 		return
 	}
 
+	if err = server.loadUploads(uploads); err != nil {
+		return
+	}
+
+	if err = server.loadAttachments(); err != nil {
+		return
+	}
+
 	go server.prune()
 
 	return
 }
 
-// Used as a globally-persisting goroutine to prune handshakes older than s.dur.
+// Gets the key names of a store, of lists if lists is set. Sealed keys are
+// hidden, so they are read from the sealed values. Sealed lists are kept as
+// blobs.
+func (s *Server) storedKeys(name string, lists bool) ([]string, error) {
+	keys, err := s.backend.Keys(name, lists && s.sealer == nil)
+
+	if err != nil || s.sealer == nil || len(keys) == 0 {
+		return keys, err
+	}
+
+	raw, err := s.backend.Blobs(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sealer.Keys(raw, keys)
+}
+
+// Used as a globally-persisting goroutine to prune handshakes, uploads and
+// unreferenced attachments older than s.dur.
 // The handshake time value should still be checked since this cannot remove
 // stale handshakes immediately.
 func (s *Server) prune() {
@@ -83,22 +447,34 @@ func (s *Server) prune() {
 	for {
 		select {
 		case now := <-ticker.C:
-			s.mu.Lock()
-			for uuid, m := range s.active {
-				if now.UTC().Sub(m.time) > s.dur {
-					delete(s.active, uuid)
-				}
-			}
-			s.mu.Unlock()
+			s.active.prune(now.UTC(), s.dur)
+			s.pruneAttachments(now.UTC())
+		case <-s.done:
+			ticker.Stop()
+			return
 		}
 	}
 }
 
-// Generates a hello response and adds it to the active handshake map.
+// Close stops pruning, closes the server's storage and unlocks its directory.
+// The server must not be used afterwards.
+func (s *Server) Close() error {
+	close(s.done)
+
+	err := s.backend.Close()
+
+	if uerr := s.unlock(); err == nil {
+		err = uerr
+	}
+
+	return err
+}
+
+// Generates a hello response and adds it to the active handshakes.
 func (s *Server) newHelloResponse(request interface{}) (*ramble.HelloResponse, error) {
 	var h ramble.HelloResponse
 
-	b, err := pgp.NonceHex()
+	b, err := s.crypto.Nonce()
 
 	if err != nil {
 		return nil, err
@@ -112,36 +488,27 @@ func (s *Server) newHelloResponse(request interface{}) (*ramble.HelloResponse, e
 		return nil, err
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if m, ok := s.active[h.UUID]; ok {
-		log.Printf("%s -> %s already exists in activeHVs!\n",
-			h.UUID, m.time.String())
-		return nil, errors.New("the very improbable just happened")
-	}
-
-	s.active[h.UUID] = verifyMeta{
+	ok := s.active.add(h.UUID, verifyMeta{
 		nonce:   h.Nonce,
 		request: request,
 		time:    time.Now().UTC(),
+	})
+
+	if !ok {
+		log.Printf("%s already exists in activeHVs!\n", h.UUID)
+		return nil, errors.New("the very improbable just happened")
 	}
 
 	return &h, nil
 }
 
 func (s *Server) verifyReq(uuid string) (*verifyMeta, error) {
-	s.mu.Lock()
-	v, ok := s.active[uuid]
+	v, ok := s.active.take(uuid)
 
 	if !ok {
-		s.mu.Unlock()
 		return nil, errors.New("no handshake with UUID")
 	}
 
-	delete(s.active, uuid)
-	s.mu.Unlock()
-
 	if time.Now().UTC().Sub(v.time) > s.dur {
 		return nil, errors.New("handshake expired")
 	}
@@ -149,12 +516,11 @@ func (s *Server) verifyReq(uuid string) (*verifyMeta, error) {
 	return &v, nil
 }
 
-func (s *Server) verifyReqSig(public []byte, sig, nonce string) error {
-	p := bytes.NewReader(public)
+func (s *Server) verifyReqSig(public pgp.Key, sig, nonce string) error {
 	sr := strings.NewReader(sig)
 	n := strings.NewReader(nonce)
 
-	t, err := pgp.VerifyArmoredSig(p, sr, n)
+	t, err := public.VerifySig(sr, n)
 
 	if err != nil {
 		return err
@@ -0,0 +1,2764 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/internal/pgp"
+	"github.com/esote/ramble/internal/store"
+	"github.com/esote/ramble/pkg/rpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fakeCrypto is a deterministic Crypto backend. Public keys are "public "
+// followed by a hex fingerprint, and optionally " revoked". Signatures are the
+// signer's fingerprint followed by the signed data. Encrypted messages are a
+// line "enc " followed by the fingerprint, or "hidden", for each recipient and
+// then the plaintext. Parsed keys are the key's text, and key IDs are
+// fingerprints.
+type fakeCrypto struct {
+	mu     sync.Mutex
+	nonce  uint64
+	parses int
+}
+
+type fakeKey string
+
+func (k fakeKey) fingerprint() string {
+	return strings.Fields(string(k))[1]
+}
+
+func (*fakeCrypto) Algorithms() []string {
+	return []string{"fake"}
+}
+
+func (*fakeCrypto) Fingerprint(public io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(public)
+
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(b))
+
+	if len(fields) < 2 || fields[0] != "public" {
+		return "", errors.New("not a public key")
+	}
+
+	return fields[1], nil
+}
+
+func (c *fakeCrypto) Nonce() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nonce++
+
+	return []byte(fmt.Sprintf("%016x", c.nonce)), nil
+}
+
+func (c *fakeCrypto) ParseKey(public io.Reader) (pgp.Key, error) {
+	b, err := ioutil.ReadAll(public)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = c.Fingerprint(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.parses++
+	c.mu.Unlock()
+
+	return fakeKey(b), nil
+}
+
+func (*fakeCrypto) Recipients(input io.Reader) ([]pgp.KeyID, error) {
+	b, err := ioutil.ReadAll(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []pgp.KeyID
+
+	for _, line := range strings.Split(string(b), "\n") {
+		if !strings.HasPrefix(line, "enc ") {
+			break
+		}
+
+		id := pgp.KeyID(line[len("enc "):])
+
+		if id == "hidden" {
+			id = ""
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+
+	return ids, nil
+}
+
+func (*fakeCrypto) VerifyEncrypted(input io.Reader) (bool, error) {
+	b, err := ioutil.ReadAll(input)
+
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.HasPrefix(b, []byte("enc ")), nil
+}
+
+func (*fakeCrypto) VerifyFingerprint(fingerprint string) bool {
+	return pgp.VerifyHexFingerprint(fingerprint)
+}
+
+func (*fakeCrypto) VerifyPublic(input io.Reader) (bool, error) {
+	if _, err := (*fakeCrypto)(nil).Fingerprint(input); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func (k fakeKey) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	if _, err := io.WriteString(w, "enc "+k.fingerprint()+"\n"); err != nil {
+		return nil, err
+	}
+
+	return nopCloser{w}, nil
+}
+
+func (k fakeKey) KeyIDs() []pgp.KeyID {
+	return []pgp.KeyID{pgp.KeyID(k.fingerprint())}
+}
+
+func (k fakeKey) VerifySig(sig, file io.Reader) (time.Time, error) {
+	var t time.Time
+
+	if err := k.VerifyUsable(time.Now()); err != nil {
+		return t, err
+	}
+
+	s, err := ioutil.ReadAll(sig)
+
+	if err != nil {
+		return t, err
+	}
+
+	b, err := ioutil.ReadAll(file)
+
+	if err != nil {
+		return t, err
+	}
+
+	if string(s) != fakeSign(k.fingerprint(), string(b)) {
+		return t, errors.New("bad signature")
+	}
+
+	return time.Now().UTC(), nil
+}
+
+func (k fakeKey) VerifyUsable(t time.Time) error {
+	if strings.HasSuffix(string(k), " revoked") {
+		return pgp.ErrKeyRevoked
+	}
+
+	return nil
+}
+
+func fakePublic(fingerprint string) string {
+	return "public " + fingerprint
+}
+
+func fakeSign(fingerprint, data string) string {
+	return fingerprint + " " + data
+}
+
+func fakeMessage(recipients ...string) string {
+	var msg string
+
+	for _, r := range recipients {
+		msg += "enc " + r + "\n"
+	}
+
+	return msg + "message"
+}
+
+func fakeFingerprint(i int) string {
+	return fmt.Sprintf("%040x", i)
+}
+
+func newTestServer(t *testing.T, masterKeys ...[]byte) *Server {
+	s, err := NewServer(&Config{
+		Crypto:     new(fakeCrypto),
+		Dir:        t.TempDir(),
+		Dur:        time.Minute,
+		MasterKeys: masterKeys,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+func newBoltServer(t *testing.T) *Server {
+	s, err := NewServer(&Config{
+		Backend: BackendBolt,
+		Crypto:  new(fakeCrypto),
+		Dir:     t.TempDir(),
+		Dur:     time.Minute,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+func welcome(t *testing.T, s *Server, public string) {
+	hello, err := s.WelcomeHello(&ramble.WelcomeHelloReq{
+		Public: public,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := s.crypto.Fingerprint(strings.NewReader(public))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.WelcomeVerify(&ramble.WelcomeVerifyReq{
+		Signature: fakeSign(f, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func send(s *Server, sender, conv string, recipients ...string) (string, error) {
+	return sendMessage(s, sender, conv, fakeMessage(recipients...),
+		recipients...)
+}
+
+func sendMessage(s *Server, sender, conv, msg string, recipients ...string) (string, error) {
+	hello, err := s.SendHello(&ramble.SendHelloReq{
+		Conversation: conv,
+		Message:      msg,
+		Recipients:   recipients,
+		Sender:       sender,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.SendVerify(&ramble.SendVerifyReq{
+		Signature: fakeSign(sender, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Conversation, nil
+}
+
+func viewConversations(s *Server, sender string) ([]string, error) {
+	hello, err := s.ViewHello(&ramble.ViewHelloReq{
+		Count:  100,
+		Sender: sender,
+		Type:   ramble.ViewConversations,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.ViewVerify(&ramble.ViewVerifyReq{
+		Signature: fakeSign(sender, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "enc " + sender + "\n"
+
+	if !strings.HasPrefix(resp.List, prefix) {
+		return nil, errors.New("list not encrypted to sender")
+	}
+
+	return strings.Fields(resp.List[len(prefix):]), nil
+}
+
+func subscribe(t *testing.T, s *Server, sender string) *Subscription {
+	hello, err := s.SubscribeHello(&ramble.SubscribeHelloReq{
+		Sender: sender,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := s.SubscribeVerify(&ramble.SubscribeVerifyReq{
+		Signature: fakeSign(sender, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sub
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestSendView sends a message and checks both participants can see the
+// conversation.
+func TestSendView(t *testing.T) {
+	testSendView(t, newTestServer(t))
+}
+
+// TestSendViewSealed is TestSendView with encryption at rest.
+func TestSendViewSealed(t *testing.T) {
+	testSendView(t, newTestServer(t, bytes.Repeat([]byte{1}, 32)))
+}
+
+// TestSendViewBolt is TestSendView with the bbolt backend.
+func TestSendViewBolt(t *testing.T) {
+	testSendView(t, newBoltServer(t))
+}
+
+func testSendView(t *testing.T, s *Server) {
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{a, b} {
+		convos, err := viewConversations(s, f)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !contains(convos, conv) {
+			t.Fatalf("%s cannot see conversation", f)
+		}
+	}
+}
+
+// Counts the bytes written to it, and records the peak heap size while they are
+// written.
+type peakWriter struct {
+	n    int
+	peak uint64
+}
+
+func (p *peakWriter) Write(b []byte) (int, error) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	if m.HeapAlloc > p.peak {
+		p.peak = m.HeapAlloc
+	}
+
+	p.n += len(b)
+
+	return len(b), nil
+}
+
+// TestViewStream checks a view is written as it is read, so that the memory it
+// needs does not grow with the size of the list.
+func TestViewStream(t *testing.T) {
+	const (
+		count = 128
+		size  = 256 << 10
+	)
+
+	s := newTestServer(t)
+	a := fakeFingerprint(1)
+
+	welcome(t, s, fakePublic(a))
+
+	msg := bytes.Repeat([]byte{'m'}, size)
+
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%032x", i)
+
+		if err := s.msg.Write(id, msg); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.tmsgs.Insert(a, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hello, err := s.ViewHello(&ramble.ViewHelloReq{
+		Count:  count,
+		Sender: a,
+		Type:   ramble.ViewMessages,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+
+	w := new(peakWriter)
+	err = s.ViewVerifyTo(&ramble.ViewVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	}, w)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.n < count*(size+1) {
+		t.Fatalf("view wrote %d bytes", w.n)
+	}
+
+	if w.peak > m.HeapAlloc+count*size/4 {
+		t.Fatalf("heap grew %d bytes viewing %d", w.peak-m.HeapAlloc,
+			count*size)
+	}
+}
+
+// TestSendRecipients checks that messages must be encrypted to exactly the
+// listed recipients.
+func TestSendRecipients(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+	welcome(t, s, fakePublic(c))
+
+	tests := []struct {
+		msg        string
+		recipients []string
+		ok         bool
+	}{
+		{fakeMessage(a, b), []string{a, b}, true},
+		{fakeMessage(b, a), []string{a, b}, true},
+		{fakeMessage("hidden", b), []string{a, b}, true},
+		{fakeMessage("hidden"), []string{a, b}, false},
+		{fakeMessage(a, b, c), []string{a, b}, false},
+		{fakeMessage(a, c), []string{a, b}, false},
+		{fakeMessage(b, b), []string{a, b}, false},
+	}
+
+	for i, test := range tests {
+		_, err := sendMessage(s, a, "", test.msg, test.recipients...)
+
+		if test.ok && err != nil {
+			t.Errorf("index=%d: %v", i, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("index=%d: accepted", i)
+		}
+	}
+}
+
+// TestVerifyBadSignature checks that a handshake is rejected, and consumed,
+// when the nonce signature is wrong.
+func TestVerifyBadSignature(t *testing.T) {
+	s := newTestServer(t)
+	a := fakeFingerprint(1)
+
+	welcome(t, s, fakePublic(a))
+
+	hello, err := s.ViewHello(&ramble.ViewHelloReq{
+		Count:  1,
+		Sender: a,
+		Type:   ramble.ViewConversations,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.ViewVerify(&ramble.ViewVerifyReq{
+		Signature: fakeSign(a, "wrong"),
+		UUID:      hello.UUID,
+	})
+
+	if err == nil {
+		t.Fatal("bad signature accepted")
+	}
+
+	_, err = s.ViewVerify(&ramble.ViewVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err == nil {
+		t.Fatal("handshake reused")
+	}
+}
+
+// TestRotate rotates a key and checks that conversations move to the new key
+// and that lookups follow the rotation.
+func TestRotate(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := subscribe(t, s, a)
+	defer sub.Close()
+
+	hello, err := s.RotateHello(&ramble.RotateHelloReq{
+		Public: fakePublic(c),
+		Sender: a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.RotateVerify(&ramble.RotateVerifyReq{
+		NewSignature: fakeSign(c, hello.Nonce),
+		Signature:    fakeSign(a, hello.Nonce),
+		UUID:         hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Fingerprint != c {
+		t.Fatal("rotated fingerprint mismatch")
+	}
+
+	lookup, err := s.Lookup(&ramble.LookupReq{
+		Fingerprint: a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lookup.Fingerprint != c {
+		t.Fatal("lookup did not follow rotation")
+	}
+
+	convos, err := viewConversations(s, c)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !contains(convos, conv) {
+		t.Fatal("conversation not moved to new key")
+	}
+
+	if _, err = viewConversations(s, a); err == nil {
+		t.Fatal("old key still usable")
+	}
+
+	if _, err = send(s, b, conv, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if notice := <-sub.C; notice.Conversation != conv {
+		t.Fatalf("notice mismatch: %+v", notice)
+	}
+}
+
+// TestRotateRollback checks that a rotation which cannot be applied leaves the
+// old key owning its conversations.
+func TestRotateRollback(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.journal.RegisterLists(storeConvos, failLists{s.tconvos, a})
+
+	hello, err := s.RotateHello(&ramble.RotateHelloReq{
+		Public: fakePublic(c),
+		Sender: a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.RotateVerify(&ramble.RotateVerifyReq{
+		NewSignature: fakeSign(c, hello.Nonce),
+		Signature:    fakeSign(a, hello.Nonce),
+		UUID:         hello.UUID,
+	})
+
+	if err == nil {
+		t.Fatal("failing rotation succeeded")
+	}
+
+	convos, err := viewConversations(s, a)
+
+	if err != nil || !contains(convos, conv) {
+		t.Fatalf("old key conversations %v, %v", convos, err)
+	}
+
+	if _, err = viewConversations(s, c); err == nil {
+		t.Fatal("new key usable after rollback")
+	}
+
+	if _, err = s.rotated.Read(a); !os.IsNotExist(err) {
+		t.Fatal("rotation pointer not rolled back")
+	}
+}
+
+// TestWelcomeRevoked checks that the owner of a stored key may replace it with
+// its revocation, after which it can no longer be used, and that others
+// replaying the revocation cannot.
+func TestWelcomeRevoked(t *testing.T) {
+	s := newTestServer(t)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	_, err := s.WelcomeHello(&ramble.WelcomeHelloReq{
+		Public: fakePublic(a) + " revoked",
+	})
+
+	if err == nil {
+		t.Fatal("revoked key welcomed without a stored key")
+	}
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	revoke := func(signer string) error {
+		hello, err := s.WelcomeHello(&ramble.WelcomeHelloReq{
+			Public: fakePublic(a) + " revoked",
+		})
+
+		if err != nil {
+			return err
+		}
+
+		_, err = s.WelcomeVerify(&ramble.WelcomeVerifyReq{
+			Signature: fakeSign(signer, hello.Nonce),
+			UUID:      hello.UUID,
+		})
+
+		return err
+	}
+
+	if err = revoke(b); err == nil {
+		t.Fatal("replayed revocation welcomed")
+	}
+
+	if _, err = send(s, a, "", b); err != nil {
+		t.Fatalf("key unusable after replayed revocation: %v", err)
+	}
+
+	if err = revoke(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = send(s, a, "", b); err == nil {
+		t.Fatal("revoked key used")
+	}
+
+	hello, err := s.WelcomeHello(&ramble.WelcomeHelloReq{
+		Public: fakePublic(a),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.WelcomeVerify(&ramble.WelcomeVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err == nil {
+		t.Fatal("revocation undone by welcoming the unrevoked key")
+	}
+
+	if _, err = send(s, a, "", b); err == nil {
+		t.Fatal("revoked key used after welcoming the unrevoked key")
+	}
+}
+
+// TestMailbox sends to blinded mailboxes and checks only the mailbox key holder
+// can view them.
+func TestMailbox(t *testing.T) {
+	s := newTestServer(t)
+	s.buckets = []int{256}
+	a := fakeFingerprint(1)
+
+	welcome(t, s, fakePublic(a))
+
+	token := ramble.MailboxToken([]byte("key"), fakeFingerprint(2))
+	address := ramble.MailboxAddress(token)
+	other := ramble.MailboxAddress(ramble.MailboxToken([]byte("key"),
+		fakeFingerprint(3)))
+
+	msg := fakeMessage(fakeFingerprint(2))
+	_, err := s.SendHello(&ramble.SendHelloReq{
+		Mailboxes: []string{address},
+		Message:   msg,
+		Sender:    a,
+	})
+
+	if err == nil {
+		t.Fatal("message to mailbox revealed recipient")
+	}
+
+	msg = fakeMessage("hidden")
+	_, err = s.SendHello(&ramble.SendHelloReq{
+		Mailboxes:  []string{address},
+		Message:    msg,
+		Recipients: []string{a},
+		Sender:     a,
+	})
+
+	if err == nil {
+		t.Fatal("both recipients and mailboxes accepted")
+	}
+
+	sendSealed := func(conv, credential string, mailboxes ...string) (*ramble.SendVerifyResp, error) {
+		hidden := make([]string, len(mailboxes))
+
+		for i := range hidden {
+			hidden[i] = "hidden"
+		}
+
+		hello, err := s.SendHello(&ramble.SendHelloReq{
+			Conversation: conv,
+			Mailboxes:    mailboxes,
+			Message:      fakeMessage(hidden...),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return s.SendVerify(&ramble.SendVerifyReq{
+			Signature: credential,
+			UUID:      hello.UUID,
+		})
+	}
+
+	// Senders to mailboxes need not be named.
+	resp, err := sendSealed("", "", address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = sendSealed(resp.Conversation, "", address); err == nil {
+		t.Fatal("sealed send without credential accepted")
+	}
+
+	if _, err = sendSealed(resp.Conversation, resp.Credential, address,
+		other); err == nil {
+		t.Fatal("sealed send added a mailbox")
+	}
+
+	if _, err = sendSealed(resp.Conversation, resp.Credential,
+		address); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = viewConversations(s, a); err == nil {
+		t.Fatal("conversation linked to a key")
+	}
+
+	view := func(sign func(nonce string) string) (string, error) {
+		hello, err := s.ViewHello(&ramble.ViewHelloReq{
+			Count:   1,
+			Mailbox: address,
+			Type:    ramble.ViewConversations,
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := s.ViewVerify(&ramble.ViewVerifyReq{
+			Signature: sign(hello.Nonce),
+			UUID:      hello.UUID,
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		return resp.List, nil
+	}
+
+	sign := func(nonce string) string {
+		return ramble.MailboxSign(token, nonce)
+	}
+
+	replayed := func(string) string {
+		return ramble.MailboxSign(token, "old nonce")
+	}
+
+	wrong := func(nonce string) string {
+		return ramble.MailboxSign("wrong", nonce)
+	}
+
+	if _, err = view(replayed); err == nil {
+		t.Fatal("viewed mailbox with replayed signature")
+	}
+
+	if _, err = view(wrong); err == nil {
+		t.Fatal("viewed mailbox with another key")
+	}
+
+	list, err := view(sign)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 256 {
+		t.Fatalf("mailbox list not padded, length %d", len(list))
+	}
+
+	if convos := strings.Fields(list); len(convos) != 1 ||
+		convos[0] != resp.Conversation {
+		t.Fatal("mailbox list mismatch")
+	}
+
+	del, err := s.DeleteHello(&ramble.DeleteHelloReq{
+		Mailbox: address,
+		Type:    ramble.DeleteConversations,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.DeleteVerify(&ramble.DeleteVerifyReq{
+		Signature: sign(del.Nonce),
+		UUID:      del.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = view(sign); err == nil {
+		t.Fatal("deleted mailbox viewed")
+	}
+}
+
+// TestSealedSender checks that the credential issued with a new conversation
+// authorizes sending without naming the sender, to existing members only.
+func TestSealedSender(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+	welcome(t, s, fakePublic(c))
+
+	hello, err := s.SendHello(&ramble.SendHelloReq{
+		Message:    fakeMessage(b),
+		Recipients: []string{b},
+		Sender:     a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.SendVerify(&ramble.SendVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Credential == "" {
+		t.Fatal("no credential for new conversation")
+	}
+
+	sealed := func(conv, credential, r string) (*ramble.SendVerifyResp, error) {
+		hello, err := s.SendHello(&ramble.SendHelloReq{
+			Conversation: conv,
+			Message:      fakeMessage(r),
+			Recipients:   []string{r},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return s.SendVerify(&ramble.SendVerifyReq{
+			Signature: credential,
+			UUID:      hello.UUID,
+		})
+	}
+
+	if _, err = sealed("", resp.Credential, b); err == nil {
+		t.Fatal("sealed sender created conversation")
+	}
+
+	if _, err = sealed(resp.Conversation, "wrong", b); err == nil {
+		t.Fatal("wrong credential accepted")
+	}
+
+	if _, err = sealed(resp.Conversation, resp.Credential, c); err == nil {
+		t.Fatal("sealed sender added a member")
+	}
+
+	if _, err = viewConversations(s, c); err == nil {
+		t.Fatal("conversation linked to outsider")
+	}
+
+	again, err := sealed(resp.Conversation, resp.Credential, b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Credential != "" {
+		t.Fatal("credential reissued for existing conversation")
+	}
+}
+
+// TestSubscribe checks that subscribers are notified of messages in each of
+// their conversations, also those which do not name them, until the
+// conversations are deleted.
+func TestSubscribe(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+	welcome(t, s, fakePublic(c))
+
+	sub := subscribe(t, s, a)
+
+	conv, err := send(s, b, "", a, c)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The member subscribes after joining and is not named again.
+	member := subscribe(t, s, c)
+
+	if _, err = send(s, b, conv, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = send(s, b, "", b); err != nil {
+		t.Fatal(err)
+	}
+
+	for seq := uint64(0); seq < 2; seq++ {
+		notice := <-sub.C
+
+		if notice.Conversation != conv || notice.Sequence != seq {
+			t.Fatalf("notice mismatch: %+v", notice)
+		}
+	}
+
+	if notice := <-member.C; notice.Conversation != conv ||
+		notice.Sequence != 1 {
+		t.Fatalf("member notice mismatch: %+v", notice)
+	}
+
+	del, err := s.DeleteHello(&ramble.DeleteHelloReq{
+		Sender: c,
+		Type:   ramble.DeleteConversations,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.DeleteVerify(&ramble.DeleteVerifyReq{
+		Signature: fakeSign(c, del.Nonce),
+		UUID:      del.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = send(s, b, conv, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if notice := <-sub.C; notice.Sequence != 2 {
+		t.Fatalf("notice mismatch: %+v", notice)
+	}
+
+	sub.Close()
+	member.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Fatal("notice for another conversation")
+	}
+
+	if _, ok := <-member.C; ok {
+		t.Fatal("notice after deleting conversations")
+	}
+}
+
+// TestSession performs requests within an authenticated session.
+func TestSession(t *testing.T) {
+	s := newTestServer(t)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	hello, err := s.SessionHello(&ramble.SessionHelloReq{
+		Sender: a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := s.SessionVerify(&ramble.SessionVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sess.Close()
+
+	sent, err := sess.Send(&ramble.SendHelloReq{
+		Message:    fakeMessage(b),
+		Recipients: []string{b},
+		Sender:     b,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notice := <-sess.Notices(); notice.Conversation != sent.Conversation {
+		t.Fatal("notice mismatch")
+	}
+
+	view, err := sess.View(&ramble.ViewHelloReq{
+		Count: 1,
+		Type:  ramble.ViewConversations,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if view.List != "enc "+a+"\n"+sent.Conversation+"\n" {
+		t.Fatal("session sender not used")
+	}
+
+	_, err = sess.Delete(&ramble.DeleteHelloReq{
+		Type: ramble.DeleteAll,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sess.View(&ramble.ViewHelloReq{
+		Count: 1,
+		Type:  ramble.ViewConversations,
+	})
+
+	if err == nil {
+		t.Fatal("session used after its key was deleted")
+	}
+}
+
+// TestSessionUsable checks sessions stop once their sender's key is revoked, and
+// are closed once they have been open for SessionDur.
+func TestSessionUsable(t *testing.T) {
+	s := newTestServer(t)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	open := func(sender string) *Session {
+		hello, err := s.SessionHello(&ramble.SessionHelloReq{
+			Sender: sender,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sess, err := s.SessionVerify(&ramble.SessionVerifyReq{
+			Signature: fakeSign(sender, hello.Nonce),
+			UUID:      hello.UUID,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(sess.Close)
+
+		return sess
+	}
+
+	sess := open(a)
+	welcome(t, s, fakePublic(a)+" revoked")
+
+	_, err := sess.Send(&ramble.SendHelloReq{
+		Message:    fakeMessage(b),
+		Recipients: []string{b},
+	})
+
+	if err == nil {
+		t.Fatal("session of revoked key sent")
+	}
+
+	s.sessDur = 10 * time.Millisecond
+	sess = open(b)
+
+	select {
+	case _, ok := <-sess.Notices():
+		if ok {
+			t.Fatal("notice without a message")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session not closed")
+	}
+
+	_, err = sess.View(&ramble.ViewHelloReq{
+		Count: 1,
+		Type:  ramble.ViewConversations,
+	})
+
+	if err != errSessionExpired {
+		t.Fatalf("expired session viewed: %v", err)
+	}
+}
+
+// TestInfo checks the advertised limits are enforced, and that requests of
+// another protocol version are rejected.
+func TestInfo(t *testing.T) {
+	s, err := NewServer(&Config{
+		Crypto:         new(fakeCrypto),
+		Dir:            t.TempDir(),
+		Dur:            time.Minute,
+		MaxCount:       10,
+		MaxMessageSize: 256,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	info := s.Info([]string{"send"})
+
+	if info.Version != ramble.Version {
+		t.Fatalf("version %d, want %d", info.Version, ramble.Version)
+	}
+
+	if info.Limits.HandshakeTTL != 60 || info.Limits.MaxCount != 10 ||
+		info.Limits.MaxMessageSize != 256 {
+		t.Fatalf("limits %+v", info.Limits)
+	}
+
+	if !contains(info.Requests, "send") ||
+		!contains(info.Algorithms, "fake") {
+		t.Fatalf("info %+v", info)
+	}
+
+	a := fakeFingerprint(1)
+	b := fakeFingerprint(2)
+	welcome(t, s, "public "+a)
+	welcome(t, s, "public "+b)
+
+	if _, err = send(s, a, "", b); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := fakeMessage(b) + strings.Repeat("x", 256)
+
+	if _, err = sendMessage(s, a, "", msg, b); err == nil {
+		t.Fatal("message over size limit accepted")
+	}
+
+	_, err = s.ViewHello(&ramble.ViewHelloReq{
+		Count:  11,
+		Sender: a,
+		Type:   ramble.ViewConversations,
+	})
+
+	if err == nil {
+		t.Fatal("count over limit accepted")
+	}
+
+	_, err = s.ViewHello(&ramble.ViewHelloReq{
+		Count:   10,
+		Sender:  a,
+		Type:    ramble.ViewConversations,
+		Version: ramble.Version,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.ViewHello(&ramble.ViewHelloReq{
+		Count:   10,
+		Sender:  a,
+		Type:    ramble.ViewConversations,
+		Version: ramble.Version + 1,
+	})
+
+	var verr *VersionError
+
+	if !errors.As(err, &verr) || verr.Version != ramble.Version+1 {
+		t.Fatalf("want VersionError, got %v", err)
+	}
+}
+
+// TestRPCParity checks the gRPC service has a method for each request type and
+// the fields of each JSON type, so that neither transport falls behind.
+func TestRPCParity(t *testing.T) {
+	methods := make(map[string]bool)
+
+	for _, m := range rpc.Ramble_ServiceDesc.Methods {
+		methods[m.MethodName] = true
+	}
+
+	for _, st := range rpc.Ramble_ServiceDesc.Streams {
+		methods[st.StreamName] = true
+	}
+
+	for _, req := range Requests {
+		name := strings.ToUpper(req[:1]) + req[1:]
+
+		if !methods[name] && !methods[name+"Hello"] {
+			t.Errorf("no method for %s requests", req)
+		}
+	}
+
+	for _, pair := range []struct {
+		json interface{}
+		msg  proto.Message
+	}{
+		{ramble.BatchSendHelloReq{}, new(rpc.BatchSendHelloReq)},
+		{ramble.BatchSendItem{}, new(rpc.BatchSendItem)},
+		{ramble.BatchSendResult{}, new(rpc.BatchSendResult)},
+		{ramble.BatchSendVerifyResp{}, new(rpc.BatchSendVerifyResp)},
+		{ramble.DeleteHelloReq{}, new(rpc.DeleteHelloReq)},
+		{ramble.DownloadReq{}, new(rpc.DownloadReq)},
+		{ramble.DownloadResp{}, new(rpc.DownloadResp)},
+		{ramble.Frame{}, new(rpc.Frame)},
+		{ramble.HelloResponse{}, new(rpc.HelloResponse)},
+		{ramble.InfoResp{}, new(rpc.InfoResp)},
+		{ramble.Limits{}, new(rpc.Limits)},
+		{ramble.LookupReq{}, new(rpc.LookupReq)},
+		{ramble.LookupResp{}, new(rpc.LookupResp)},
+		{ramble.Notice{}, new(rpc.Notice)},
+		{ramble.RotateHelloReq{}, new(rpc.RotateHelloReq)},
+		{ramble.RotateVerifyReq{}, new(rpc.RotateVerifyReq)},
+		{ramble.RotateVerifyResp{}, new(rpc.RotateVerifyResp)},
+		{ramble.SendHelloReq{}, new(rpc.SendHelloReq)},
+		{ramble.SendVerifyResp{}, new(rpc.SendVerifyResp)},
+		{ramble.SubscribeHelloReq{}, new(rpc.SubscribeHelloReq)},
+		{ramble.UploadChunkReq{}, new(rpc.UploadChunkReq)},
+		{ramble.UploadChunkResp{}, new(rpc.UploadChunkResp)},
+		{ramble.UploadHelloReq{}, new(rpc.UploadHelloReq)},
+		{ramble.UploadVerifyResp{}, new(rpc.UploadVerifyResp)},
+		{ramble.VerifyRequest{}, new(rpc.VerifyRequest)},
+		{ramble.ViewHelloReq{}, new(rpc.ViewHelloReq)},
+		{ramble.ViewVerifyResp{}, new(rpc.ViewVerifyResp)},
+		{ramble.WelcomeHelloReq{}, new(rpc.WelcomeHelloReq)},
+	} {
+		typ := reflect.TypeOf(pair.json)
+		fields := pair.msg.ProtoReflect().Descriptor().Fields()
+
+		if typ.NumField() != fields.Len() {
+			t.Errorf("%s has %d fields, message has %d", typ.Name(),
+				typ.NumField(), fields.Len())
+		}
+
+		for i := 0; i < typ.NumField(); i++ {
+			name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+
+			if fields.ByName(protoreflect.Name(name)) == nil {
+				t.Errorf("message %s has no field %s", typ.Name(),
+					name)
+			}
+		}
+	}
+}
+
+// TestBatchSend sends a batch with one invalid item, and checks the others are
+// sent under the one signature.
+func TestBatchSend(t *testing.T) {
+	s := newTestServer(t)
+	a, b, c := fakeFingerprint(1), fakeFingerprint(2), fakeFingerprint(3)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+	welcome(t, s, fakePublic(c))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello, err := s.BatchSendHello(&ramble.BatchSendHelloReq{
+		Items: []ramble.BatchSendItem{
+			{
+				Conversation: conv,
+				Message:      fakeMessage(b),
+				Recipients:   []string{b},
+			},
+			{
+				Message:    fakeMessage(c),
+				Recipients: []string{b},
+			},
+			{
+				Message:    fakeMessage(c),
+				Recipients: []string{c},
+			},
+		},
+		Sender: a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.BatchSendVerify(&ramble.BatchSendVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Results) != 3 {
+		t.Fatalf("got %d results", len(resp.Results))
+	}
+
+	if r := resp.Results[0]; r.Conversation != conv || r.Credential != "" ||
+		r.Error != "" {
+		t.Fatalf("existing conversation result %+v", r)
+	}
+
+	if r := resp.Results[1]; r.Conversation != "" ||
+		r.Error != "bad request" {
+		t.Fatalf("invalid item result %+v", r)
+	}
+
+	r := resp.Results[2]
+
+	if r.Conversation == "" || r.Credential == "" || r.Error != "" {
+		t.Fatalf("new conversation result %+v", r)
+	}
+
+	convos, err := viewConversations(s, c)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(convos) != 1 || convos[0] != r.Conversation {
+		t.Fatalf("recipient conversations %v", convos)
+	}
+
+	convos, err = viewConversations(s, b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(convos) != 1 || convos[0] != conv {
+		t.Fatalf("recipient conversations %v", convos)
+	}
+
+	_, err = s.BatchSendHello(&ramble.BatchSendHelloReq{
+		Sender: a,
+	})
+
+	if err == nil {
+		t.Fatal("empty batch accepted")
+	}
+}
+
+func startUpload(t *testing.T, s *Server, sender string, size int64) string {
+	hello, err := s.UploadHello(&ramble.UploadHelloReq{
+		Sender: sender,
+		Size:   size,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.UploadVerify(&ramble.UploadVerifyReq{
+		Signature: fakeSign(sender, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.ChunkSize != s.chunk {
+		t.Fatalf("chunk size %d, want %d", resp.ChunkSize, s.chunk)
+	}
+
+	return resp.Upload
+}
+
+func uploadChunk(s *Server, upload string, offset int64, data string) (*ramble.UploadChunkResp, error) {
+	return s.UploadChunk(&ramble.UploadChunkReq{
+		Data:   []byte(data),
+		Offset: offset,
+		Upload: upload,
+	})
+}
+
+// TestAttachment uploads an attachment in chunks, resuming part way, and checks
+// it can be read back once referenced while unreferenced attachments and
+// abandoned uploads are collected.
+func TestAttachment(t *testing.T) {
+	s := newTestServer(t)
+	s.chunk = 4
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	upload := startUpload(t, s, a, 10)
+
+	if _, err := uploadChunk(s, upload, 0, "0123"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uploadChunk(s, upload, 0, "0123"); err == nil {
+		t.Fatal("chunk at wrong offset accepted")
+	}
+
+	if _, err := uploadChunk(s, upload, 4, "45"); err == nil {
+		t.Fatal("short chunk accepted")
+	}
+
+	resp, err := uploadChunk(s, upload, 0, "")
+
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Offset != 4 {
+		t.Fatalf("resume offset %d", resp.Offset)
+	}
+
+	if _, err = uploadChunk(s, upload, 4, "4567"); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err = uploadChunk(s, upload, 8, "89"); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("0123456789"))
+	h := hex.EncodeToString(sum[:])
+
+	if resp.Hash != h {
+		t.Fatalf("hash %s, want %s", resp.Hash, h)
+	}
+
+	dl, err := s.Download(&ramble.DownloadReq{
+		Hash:   h,
+		Length: 4,
+		Offset: 3,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if string(dl.Data) != "3456" || dl.Size != 10 {
+		t.Fatalf("download %q size %d", dl.Data, dl.Size)
+	}
+
+	att, err := s.OpenAttachment(h)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = att.Seek(-5, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+
+	if rest, err := ioutil.ReadAll(att); err != nil {
+		t.Fatal(err)
+	} else if string(rest) != "56789" {
+		t.Fatalf("read %q", rest)
+	}
+
+	unused := startUpload(t, s, a, 3)
+
+	if resp, err = uploadChunk(s, unused, 0, "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	unusedHash := resp.Hash
+	abandoned := startUpload(t, s, a, 8)
+
+	if _, err = uploadChunk(s, abandoned, 0, "0123"); err != nil {
+		t.Fatal(err)
+	}
+
+	hello, err := s.SendHello(&ramble.SendHelloReq{
+		Attachments: []string{h},
+		Message:     fakeMessage(b),
+		Recipients:  []string{b},
+		Sender:      a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.SendVerify(&ramble.SendVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.pruneAttachments(time.Now().UTC().Add(2 * s.dur))
+
+	if _, err = s.OpenAttachment(h); err != nil {
+		t.Fatalf("referenced attachment collected: %v", err)
+	}
+
+	if _, err = s.OpenAttachment(unusedHash); err == nil {
+		t.Fatal("unreferenced attachment not collected")
+	}
+
+	if _, err = uploadChunk(s, abandoned, 4, "4567"); err == nil {
+		t.Fatal("abandoned upload continued")
+	}
+
+	removeReferences(t, s, h)
+	s.pruneAttachments(time.Now().UTC().Add(4 * s.dur))
+
+	if _, err = s.OpenAttachment(h); err == nil {
+		t.Fatal("attachment of removed message not collected")
+	}
+
+	_, err = s.SendHello(&ramble.SendHelloReq{
+		Attachments: []string{unusedHash},
+		Message:     fakeMessage(b),
+		Recipients:  []string{b},
+		Sender:      a,
+	})
+
+	if err == nil {
+		t.Fatal("collected attachment referenced")
+	}
+}
+
+// TestAttachmentRestart checks that a failed send leaves its attachments
+// unreferenced, and that uploads continue and unreferenced attachments are
+// collected after a restart.
+func TestAttachmentRestart(t *testing.T) {
+	config := &Config{
+		Crypto: new(fakeCrypto),
+		Dir:    t.TempDir(),
+		Dur:    time.Minute,
+	}
+
+	s, err := NewServer(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.chunk = 4
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	partial := startUpload(t, s, a, 10)
+
+	if _, err = uploadChunk(s, partial, 0, "0123"); err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []string
+
+	for _, data := range []string{"abc", "xy"} {
+		resp, err := uploadChunk(s, startUpload(t, s, a,
+			int64(len(data))), 0, data)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hashes = append(hashes, resp.Hash)
+	}
+
+	tconvos := s.tconvos
+	s.journal.RegisterLists(storeConvos, failLists{tconvos, b})
+
+	hello, err := s.SendHello(&ramble.SendHelloReq{
+		Attachments: hashes[1:],
+		Message:     fakeMessage(b),
+		Recipients:  []string{b},
+		Sender:      a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.SendVerify(&ramble.SendVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err == nil {
+		t.Fatal("failing send succeeded")
+	}
+
+	if _, err = s.tattach.IndexN(hashes[1], 0); !os.IsNotExist(err) {
+		t.Fatal("failed send referenced attachment")
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewServer(config); err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	resp, err := uploadChunk(s, partial, 0, "")
+
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Offset != 4 {
+		t.Fatalf("resume offset %d after restart", resp.Offset)
+	}
+
+	if _, err = uploadChunk(s, partial, 4, "4567"); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err = uploadChunk(s, partial, 8, "89"); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("0123456789"))
+
+	if resp.Hash != hex.EncodeToString(sum[:]) {
+		t.Fatal("restored upload hash mismatch")
+	}
+
+	s.pruneAttachments(time.Now().UTC().Add(2 * s.dur))
+
+	for _, h := range append(hashes, resp.Hash) {
+		if _, err = s.OpenAttachment(h); err == nil {
+			t.Fatalf("attachment %s not collected", h)
+		}
+	}
+
+	for _, name := range []string{storeChunks, storeUploads} {
+		if keys, err := s.backend.Keys(name, false); err != nil ||
+			len(keys) != 0 {
+			t.Fatalf("%s left %v, %v", name, keys, err)
+		}
+	}
+}
+
+// TestAttachmentRestartReferenced checks that an attachment referenced before a
+// restart is collected once its message is removed.
+func TestAttachmentRestartReferenced(t *testing.T) {
+	config := &Config{
+		Crypto: new(fakeCrypto),
+		Dir:    t.TempDir(),
+		Dur:    time.Minute,
+	}
+
+	s, err := NewServer(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	resp, err := uploadChunk(s, startUpload(t, s, a, 3), 0, "abc")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello, err := s.SendHello(&ramble.SendHelloReq{
+		Attachments: []string{resp.Hash},
+		Message:     fakeMessage(b),
+		Recipients:  []string{b},
+		Sender:      a,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.SendVerify(&ramble.SendVerifyReq{
+		Signature: fakeSign(a, hello.Nonce),
+		UUID:      hello.UUID,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewServer(config); err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	s.pruneAttachments(time.Now().UTC().Add(2 * s.dur))
+
+	if _, err = s.OpenAttachment(resp.Hash); err != nil {
+		t.Fatalf("referenced attachment collected: %v", err)
+	}
+
+	removeReferences(t, s, resp.Hash)
+	s.pruneAttachments(time.Now().UTC().Add(4 * s.dur))
+
+	if _, err = s.OpenAttachment(resp.Hash); err == nil {
+		t.Fatal("attachment of removed message not collected")
+	}
+}
+
+// Removes the messages referencing an attachment.
+func removeReferences(t *testing.T, s *Server, h string) {
+	refs, err := s.tattach.IndexN(h, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range refs {
+		if err = s.msg.Remove(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestKeyCache checks parsed keys are reused, evicted once the cache is full,
+// and not added after a removal they raced with.
+func TestKeyCache(t *testing.T) {
+	s := newTestServer(t)
+	c := s.crypto.(*fakeCrypto)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	if _, err := send(s, a, "", b); err != nil {
+		t.Fatal(err)
+	}
+
+	parses := c.parses
+
+	for i := 0; i < 3; i++ {
+		if _, err := send(s, a, "", b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c.parses != parses {
+		t.Fatalf("keys parsed %d more times", c.parses-parses)
+	}
+
+	keys := newKeyCache(2)
+	_, epoch, _ := keys.get("a")
+	keys.add("a", fakeKey("a"), epoch)
+	keys.add("b", fakeKey("b"), epoch)
+	keys.get("a")
+	keys.add("c", fakeKey("c"), epoch)
+
+	if _, _, ok := keys.get("b"); ok {
+		t.Fatal("least recently used key not evicted")
+	}
+
+	if _, _, ok := keys.get("a"); !ok {
+		t.Fatal("recently used key evicted")
+	}
+
+	_, epoch, _ = keys.get("d")
+	keys.remove("a")
+	keys.add("d", fakeKey("d"), epoch)
+
+	if _, _, ok := keys.get("d"); ok {
+		t.Fatal("key added after a removal")
+	}
+}
+
+// TestHandshakes checks handshakes are taken once and pruned once expired.
+func TestHandshakes(t *testing.T) {
+	h := newHandshakes(4)
+	now := time.Now().UTC()
+
+	for i := 0; i < 100; i++ {
+		ok := h.add(strconv.Itoa(i), verifyMeta{
+			nonce: strconv.Itoa(i),
+			time:  now.Add(time.Duration(i) * time.Second),
+		})
+
+		if !ok {
+			t.Fatalf("handshake %d not added", i)
+		}
+	}
+
+	if h.add("0", verifyMeta{time: now}) {
+		t.Fatal("duplicate handshake added")
+	}
+
+	if m, ok := h.take("0"); !ok || m.nonce != "0" {
+		t.Fatal("handshake not taken")
+	}
+
+	if _, ok := h.take("0"); ok {
+		t.Fatal("handshake taken twice")
+	}
+
+	h.prune(now.Add(time.Minute), 10*time.Second)
+
+	if n := h.len(); n != 50 {
+		t.Fatalf("%d handshakes after prune, want 50", n)
+	}
+
+	if _, ok := h.take("49"); ok {
+		t.Fatal("expired handshake not pruned")
+	}
+
+	if _, ok := h.take("50"); !ok {
+		t.Fatal("active handshake pruned")
+	}
+}
+
+// Runs hello-verify handshakes from many goroutines while pruning, with
+// thousands of handshakes active.
+func benchmarkHandshakes(b *testing.B, shards int) {
+	const active = 4096
+
+	h := newHandshakes(shards)
+	now := time.Now().UTC()
+
+	for i := 0; i < active; i++ {
+		h.add(fmt.Sprintf("active%d", i), verifyMeta{
+			time: now,
+		})
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				h.prune(now.UTC(), time.Minute)
+			}
+		}
+	}()
+
+	var n uint64
+	var mu sync.Mutex
+
+	b.SetParallelism(64)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		mu.Lock()
+		n++
+		id := n
+		mu.Unlock()
+
+		for i := 0; pb.Next(); i++ {
+			uuid := fmt.Sprintf("%d.%d", id, i)
+
+			if !h.add(uuid, verifyMeta{time: time.Now().UTC()}) {
+				b.Error("handshake not added")
+				return
+			}
+
+			if _, ok := h.take(uuid); !ok {
+				b.Error("handshake not taken")
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkHandshakesGlobal(b *testing.B) {
+	benchmarkHandshakes(b, 1)
+}
+
+func BenchmarkHandshakesSharded(b *testing.B) {
+	benchmarkHandshakes(b, handshakeShards)
+}
+
+// Prunes with thousands of handshakes active, of which few have expired.
+func BenchmarkPruneHandshakes(b *testing.B) {
+	for _, active := range []int{1000, 10000} {
+		b.Run(strconv.Itoa(active), func(b *testing.B) {
+			h := newHandshakes(handshakeShards)
+			now := time.Now().UTC()
+
+			for i := 0; i < active; i++ {
+				h.add(strconv.Itoa(i), verifyMeta{
+					time: now,
+				})
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				h.add(fmt.Sprintf("expired%d", i), verifyMeta{
+					time: now.Add(-time.Hour),
+				})
+				h.prune(now, time.Minute)
+			}
+		})
+	}
+}
+
+var errCrash = errors.New("crashed")
+
+// Counts writes to stores, failing all of them once n writes are used up as if
+// the process crashed.
+type crash struct {
+	n  int
+	mu sync.Mutex
+}
+
+func (c *crash) write() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.n == 0 {
+		return errCrash
+	}
+
+	c.n--
+	return nil
+}
+
+type crashBlobs struct {
+	store.Blobs
+	c *crash
+}
+
+func (b crashBlobs) Write(key string, value []byte) error {
+	if err := b.c.write(); err != nil {
+		return err
+	}
+
+	return b.Blobs.Write(key, value)
+}
+
+func (b crashBlobs) Remove(key string) error {
+	if err := b.c.write(); err != nil {
+		return err
+	}
+
+	return b.Blobs.Remove(key)
+}
+
+type crashLists struct {
+	store.Lists
+	c *crash
+}
+
+func (l crashLists) Insert(key, value string) error {
+	if err := l.c.write(); err != nil {
+		return err
+	}
+
+	return l.Lists.Insert(key, value)
+}
+
+func (l crashLists) InsertUnique(key, value string) error {
+	if err := l.c.write(); err != nil {
+		return err
+	}
+
+	return l.Lists.InsertUnique(key, value)
+}
+
+func (l crashLists) Remove(key string) error {
+	if err := l.c.write(); err != nil {
+		return err
+	}
+
+	return l.Lists.Remove(key)
+}
+
+// A journal log which crashes like crashBlobs. If late is set, a record is
+// written before the crash is reported.
+type crashLog struct {
+	store.Log
+	c    *crash
+	late bool
+}
+
+func (l crashLog) Append(id string, record []byte) error {
+	if err := l.c.write(); err != nil {
+		if l.late {
+			_ = l.Log.Append(id, record)
+		}
+
+		return err
+	}
+
+	return l.Log.Append(id, record)
+}
+
+func (l crashLog) Remove(id string) error {
+	if err := l.c.write(); err != nil {
+		return err
+	}
+
+	return l.Log.Remove(id)
+}
+
+// TestSendCrash crashes sends during each write of their message and of its
+// journal record, and checks that once restarted the server has either all or
+// none of the message.
+func TestSendCrash(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{
+		Crypto: new(fakeCrypto),
+		Dir:    dir,
+		Dur:    time.Minute,
+	}
+
+	s, err := NewServer(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	i := 0
+
+	for _, late := range []bool{false, true} {
+		for n := 0; ; n++ {
+			c := &crash{n: n}
+			i++
+
+			s.journal = store.NewJournal(crashLog{
+				Log:  store.NewFileLog(filepath.Join(dir, storeJournal)),
+				c:    c,
+				late: late,
+			})
+			s.journal.RegisterBlobs(storeCreds, crashBlobs{s.creds, c})
+			s.journal.RegisterBlobs(storeMessages, crashBlobs{s.msg, c})
+			s.journal.RegisterLists(storeConvos, crashLists{s.tconvos, c})
+			s.journal.RegisterLists(storeMailboxes, crashLists{s.tmailbox,
+				c})
+			s.journal.RegisterLists(storeMsgs, crashLists{s.tmsgs, c})
+
+			conv := fmt.Sprintf("%032x", i)
+			_, sendErr := send(s, a, conv, b)
+
+			if sendErr != nil && !strings.Contains(sendErr.Error(),
+				errCrash.Error()) {
+				t.Fatal(sendErr)
+			}
+
+			if n == 0 && sendErr == nil {
+				t.Fatal("send did not crash")
+			}
+
+			// The crashed server is stopped before its data is
+			// reopened.
+			if err = s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if s, err = NewServer(config); err != nil {
+				t.Fatal(err)
+			}
+
+			msgs, err := s.tmsgs.IndexN(conv, 0)
+			sent := err == nil
+
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			if sendErr == nil && !sent {
+				t.Fatalf("late=%t n=%d: sent message lost", late, n)
+			}
+
+			for _, f := range []string{a, b} {
+				convos, err := viewConversations(s, f)
+
+				if err != nil && !os.IsNotExist(err) {
+					t.Fatal(err)
+				}
+
+				if contains(convos, conv) != sent {
+					t.Fatalf("late=%t n=%d: %s sees conversation"+
+						" %t, sent %t", late, n, f, !sent, sent)
+				}
+			}
+
+			_, err = s.creds.Read(conv)
+
+			if sent != (err == nil) {
+				t.Fatalf("late=%t n=%d: credential %v, sent %t",
+					late, n, err, sent)
+			}
+
+			if !sent {
+				continue
+			}
+
+			if len(msgs) != 1 {
+				t.Fatalf("late=%t n=%d: messages %v", late, n, msgs)
+			}
+
+			if _, err = s.msg.Read(msgs[0]); err != nil {
+				t.Fatalf("late=%t n=%d: message not stored: %v",
+					late, n, err)
+			}
+
+			if sendErr == nil {
+				break
+			}
+		}
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Lists which fail inserts to and removals of one key.
+type failLists struct {
+	store.Lists
+	key string
+}
+
+func (l failLists) InsertUnique(key, value string) error {
+	if key == l.key {
+		return errCrash
+	}
+
+	return l.Lists.InsertUnique(key, value)
+}
+
+func (l failLists) Remove(key string) error {
+	if key == l.key {
+		return errCrash
+	}
+
+	return l.Lists.Remove(key)
+}
+
+// TestSendRollback checks that a send whose writes keep failing leaves nothing
+// behind, so that a client sending it again does not duplicate it.
+func TestSendRollback(t *testing.T) {
+	s := newTestServer(t)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tconvos := s.tconvos
+	s.journal.RegisterLists(storeConvos, failLists{tconvos, b})
+
+	if _, err = send(s, a, conv, b); err == nil {
+		t.Fatal("failing send succeeded")
+	}
+
+	msgs, err := s.tmsgs.IndexN(conv, 0)
+
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("messages %v, %v after rollback", msgs, err)
+	}
+
+	s.journal.RegisterLists(storeConvos, tconvos)
+
+	if _, err = send(s, a, conv, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if msgs, err = s.tmsgs.IndexN(conv, 0); err != nil || len(msgs) != 2 {
+		t.Fatalf("messages %v, %v after retry", msgs, err)
+	}
+}
+
+// TestStress runs many clients against one server, sending to shared
+// conversations while others view and delete them. It is most useful with the
+// race detector.
+func TestStress(t *testing.T) {
+	testStress(t, newTestServer(t))
+}
+
+// TestStressSealed is TestStress with encryption at rest, where lists are
+// rewritten whole on each insert.
+func TestStressSealed(t *testing.T) {
+	testStress(t, newTestServer(t, bytes.Repeat([]byte{1}, 32)))
+}
+
+// TestStressBolt is TestStress with the bbolt backend.
+func TestStressBolt(t *testing.T) {
+	testStress(t, newBoltServer(t))
+}
+
+func testStress(t *testing.T, s *Server) {
+	const (
+		clients  = 16
+		convos   = 4
+		deleters = 4
+		sends    = 10
+	)
+
+	var fingerprints, convIDs []string
+
+	for i := 0; i < clients; i++ {
+		fingerprints = append(fingerprints, fakeFingerprint(i+1))
+		welcome(t, s, fakePublic(fingerprints[i]))
+	}
+
+	for i := 0; i < convos; i++ {
+		convIDs = append(convIDs, fmt.Sprintf("%032x", i+1))
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	credentials := make(map[string]int)
+	sent := make(map[string]int)
+	seen := make(map[string]map[string]bool)
+
+	errs := make(chan error, clients)
+
+	client := func(i int) error {
+		sender := fingerprints[i]
+
+		for j := 0; j < sends; j++ {
+			conv := convIDs[(i+j)%convos]
+			peer := fingerprints[(i+j+1)%clients]
+
+			hello, err := s.SendHello(&ramble.SendHelloReq{
+				Conversation: conv,
+				Message:      fakeMessage(peer),
+				Recipients:   []string{peer},
+				Sender:       sender,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			resp, err := s.SendVerify(&ramble.SendVerifyReq{
+				Signature: fakeSign(sender, hello.Nonce),
+				UUID:      hello.UUID,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			sent[conv]++
+
+			if resp.Credential != "" {
+				credentials[conv]++
+			}
+
+			for _, f := range []string{sender, peer} {
+				if seen[f] == nil {
+					seen[f] = make(map[string]bool)
+				}
+
+				seen[f][conv] = true
+			}
+			mu.Unlock()
+
+			if _, err = viewConversations(s, sender); err != nil &&
+				!os.IsNotExist(err) {
+				return err
+			}
+
+			if i >= deleters {
+				continue
+			}
+
+			del, err := s.DeleteHello(&ramble.DeleteHelloReq{
+				Sender: sender,
+				Type:   ramble.DeleteConversations,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			_, err = s.DeleteVerify(&ramble.DeleteVerifyReq{
+				Signature: fakeSign(sender, del.Nonce),
+				UUID:      del.UUID,
+			})
+
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			if err := client(i); err != nil {
+				errs <- fmt.Errorf("client %d: %v", i, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if t.Failed() {
+		return
+	}
+
+	for _, conv := range convIDs {
+		if credentials[conv] != 1 {
+			t.Errorf("conversation %s created %d times", conv,
+				credentials[conv])
+		}
+
+		msgs, err := s.tmsgs.IndexN(conv, 0)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(msgs) != sent[conv] {
+			t.Errorf("conversation %s has %d messages, sent %d", conv,
+				len(msgs), sent[conv])
+		}
+	}
+
+	// Clients which never deleted their conversations see all of them.
+	for _, f := range fingerprints[deleters:] {
+		convos, err := viewConversations(s, f)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for conv := range seen[f] {
+			if !contains(convos, conv) {
+				t.Errorf("%s cannot see conversation %s", f, conv)
+			}
+		}
+	}
+}
+
+func TestBackend(t *testing.T) {
+	_, err := NewServer(&Config{
+		Backend: "unknown",
+		Crypto:  new(fakeCrypto),
+		Dir:     t.TempDir(),
+		Dur:     time.Minute,
+	})
+
+	if err == nil {
+		t.Fatal("unknown backend accepted")
+	}
+
+	dir := t.TempDir()
+	config := &Config{
+		Backend: BackendBolt,
+		Crypto:  new(fakeCrypto),
+		Dir:     dir,
+		Dur:     time.Minute,
+	}
+
+	s, err := NewServer(config)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewServer(config); err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Close()
+
+	convos, err := viewConversations(s, b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !contains(convos, conv) {
+		t.Fatal("conversation lost on restart")
+	}
+}
+
+// TestReadOnly checks read-only servers see the data without changing it, and
+// that a data directory is not shared with a server which writes.
+func TestReadOnly(t *testing.T) {
+	for _, backend := range []string{BackendFiles, BackendBolt} {
+		config := &Config{
+			Backend: backend,
+			Crypto:  new(fakeCrypto),
+			Dir:     t.TempDir(),
+			Dur:     time.Minute,
+		}
+
+		s, err := NewServer(config)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+		welcome(t, s, fakePublic(a))
+		welcome(t, s, fakePublic(b))
+
+		conv, err := send(s, a, "", b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		readOnly := *config
+		readOnly.ReadOnly = true
+
+		if _, err = NewServer(config); err == nil {
+			t.Fatalf("%s: second server opened", backend)
+		}
+
+		if _, err = NewServer(&readOnly); err == nil {
+			t.Fatalf("%s: read-only server opened while writing",
+				backend)
+		}
+
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r1, err := NewServer(&readOnly)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r2, err := NewServer(&readOnly)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = NewServer(config); err == nil {
+			t.Fatalf("%s: server opened while read-only", backend)
+		}
+
+		convos, err := r2.Conversations(b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(convos) != 1 || convos[0] != conv {
+			t.Fatalf("%s: conversations mismatch: %v", backend,
+				convos)
+		}
+
+		if _, err = send(r1, a, conv, b); err == nil {
+			t.Fatalf("%s: read-only server sent", backend)
+		}
+
+		if err = r1.Purge(a); err == nil {
+			t.Fatalf("%s: read-only server purged", backend)
+		}
+
+		if err = r1.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = r2.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if s, err = NewServer(config); err != nil {
+			t.Fatal(err)
+		}
+
+		if convos, err = s.Conversations(a); err != nil ||
+			len(convos) != 1 {
+			t.Fatalf("%s: read-only server changed data: %v, %v",
+				backend, convos, err)
+		}
+
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestAdmin checks the admin methods describe stored users and conversations,
+// including when encrypted at rest, and that purging a user leaves the
+// conversation to the others.
+func TestAdmin(t *testing.T) {
+	s := newTestServer(t)
+	a, b := fakeFingerprint(1), fakeFingerprint(2)
+
+	welcome(t, s, fakePublic(a))
+	welcome(t, s, fakePublic(b))
+
+	conv, err := send(s, a, "", b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = send(s, b, conv, a); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints, err := s.Fingerprints()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fingerprints) != 2 || !contains(fingerprints, a) ||
+		!contains(fingerprints, b) {
+		t.Fatalf("fingerprints mismatch: %v", fingerprints)
+	}
+
+	convos, err := s.Conversations(a)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(convos) != 1 || convos[0] != conv {
+		t.Fatalf("conversations mismatch: %v", convos)
+	}
+
+	msgs, err := s.Messages(conv)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msgs) != 2 {
+		t.Fatalf("message count %d, want 2", len(msgs))
+	}
+
+	for _, m := range msgs {
+		if m.Size != len(fakeMessage(a)) {
+			t.Fatalf("message %s size %d", m.UUID, m.Size)
+		}
+	}
+
+	if err = s.Purge("invalid"); err == nil {
+		t.Fatal("invalid fingerprint purged")
+	}
+
+	if err = s.Purge(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if fingerprints, err = s.Fingerprints(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fingerprints) != 1 || fingerprints[0] != b {
+		t.Fatalf("fingerprints mismatch after purge: %v", fingerprints)
+	}
+
+	if convos, err = s.Conversations(a); err != nil || len(convos) != 0 {
+		t.Fatalf("purged user has conversations %v, %v", convos, err)
+	}
+
+	if _, err = viewConversations(s, a); err == nil {
+		t.Fatal("purged user can view")
+	}
+
+	if convos, err = viewConversations(s, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !contains(convos, conv) {
+		t.Fatal("conversation lost by other participant")
+	}
+
+	sealed := newTestServer(t, bytes.Repeat([]byte{1}, 32))
+	welcome(t, sealed, fakePublic(a))
+	welcome(t, sealed, fakePublic(b))
+
+	if err = sealed.Purge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if fingerprints, err = sealed.Fingerprints(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fingerprints) != 1 || fingerprints[0] != a {
+		t.Fatalf("sealed fingerprints mismatch: %v", fingerprints)
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/esote/ramble"
+	"github.com/esote/ramble/internal/pgp"
+)
+
+var errSessionExpired = errors.New("session expired")
+
+// Session is authenticated as one sender, and performs requests without a
+// handshake of their own. It is closed once it has been open for the server's
+// SessionDur.
+type Session struct {
+	expires time.Time
+	s       *Server
+	sender  string
+	sub     *Subscription
+	timer   *time.Timer
+}
+
+// SessionHello processes the hello handshake step.
+func (s *Server) SessionHello(req *ramble.SessionHelloReq) (*ramble.SessionHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if !s.crypto.VerifyFingerprint(req.Sender) {
+		return nil, errors.New("sender fingerprint is invalid")
+	}
+
+	req.Sender = strings.ToLower(req.Sender)
+
+	resp, err := s.newHelloResponse(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.SessionHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// SessionVerify processes the verify handshake step, opening a session. The
+// session must be closed once the client is gone.
+func (s *Server) SessionVerify(req *ramble.SessionVerifyReq) (*Session, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hello, ok := meta.request.(*ramble.SessionHelloReq)
+
+	if !ok {
+		return nil, errors.New("request was not SessionHelloReq")
+	}
+
+	public, err := s.publicKey(hello.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.subscribe(hello.Sender, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		expires: time.Now().Add(s.sessDur),
+		s:       s,
+		sender:  hello.Sender,
+		sub:     sub,
+	}
+
+	sess.timer = time.AfterFunc(s.sessDur, sub.Close)
+
+	return sess, nil
+}
+
+// Close ends the session.
+func (sess *Session) Close() {
+	sess.timer.Stop()
+	sess.sub.Close()
+}
+
+// Notices delivers notices of new messages in the session's conversations.
+// It is closed when the session is closed.
+func (sess *Session) Notices() <-chan ramble.Notice {
+	return sess.sub.C
+}
+
+// Gets the session sender's public key, which fails once the session expires or
+// the key is deleted, rotated away from, revoked or expired.
+func (sess *Session) public() (pgp.Key, error) {
+	now := time.Now()
+
+	if now.After(sess.expires) {
+		return nil, errSessionExpired
+	}
+
+	key, err := sess.s.publicKey(sess.sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = key.VerifyUsable(now); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Send sends a message from the session's sender.
+func (sess *Session) Send(req *ramble.SendHelloReq) (*ramble.SendVerifyResp, error) {
+	req.Sender = sess.sender
+
+	if err := sess.s.checkSend(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.public(); err != nil {
+		return nil, err
+	}
+
+	return sess.s.send(req, "")
+}
+
+// View views the session sender's data.
+func (sess *Session) View(req *ramble.ViewHelloReq) (*ramble.ViewVerifyResp, error) {
+	req.Sender = sess.sender
+
+	if err := sess.s.checkView(req); err != nil {
+		return nil, err
+	}
+
+	public, err := sess.public()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var list strings.Builder
+
+	if err = sess.s.view(req, public, &list); err != nil {
+		return nil, err
+	}
+
+	return &ramble.ViewVerifyResp{
+		List: list.String(),
+	}, nil
+}
+
+// Delete deletes the session sender's data.
+func (sess *Session) Delete(req *ramble.DeleteHelloReq) (*ramble.DeleteVerifyResp, error) {
+	req.Sender = sess.sender
+
+	if err := sess.s.checkDelete(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.public(); err != nil {
+		return nil, err
+	}
+
+	if err := sess.s.delete(req); err != nil {
+		return nil, err
+	}
+
+	return new(ramble.DeleteVerifyResp), nil
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/esote/ramble"
+)
+
+// Notices buffered for each subscription before further notices are dropped.
+const noticeBuffer = 64
+
+// Subscription receives notices of new messages in a subscriber's
+// conversations.
+type Subscription struct {
+	// C delivers notices. It is closed when the subscription is closed.
+	C <-chan ramble.Notice
+
+	c    chan ramble.Notice
+	key  string // Guarded by the server's subMu.
+	once sync.Once
+	s    *Server
+}
+
+// Subscriptions of one key, and the conversations the key is a member of.
+type subscriber struct {
+	convos map[string]struct{}
+	subs   map[*Subscription]struct{}
+}
+
+// Close stops delivery of notices and closes C.
+func (sub *Subscription) Close() {
+	sub.once.Do(func() {
+		sub.s.subMu.Lock()
+		defer sub.s.subMu.Unlock()
+
+		sb := sub.s.subs[sub.key]
+		delete(sb.subs, sub)
+
+		if len(sb.subs) == 0 {
+			sub.s.leave(sub.key)
+			delete(sub.s.subs, sub.key)
+		}
+
+		close(sub.c)
+	})
+}
+
+// Subscriptions are keyed by fingerprint, or by prefixed mailbox address as
+// addresses and v6 fingerprints have the same length.
+func mailboxKey(address string) string {
+	return "mailbox:" + address
+}
+
+// SubscribeHello processes the hello handshake step.
+func (s *Server) SubscribeHello(req *ramble.SubscribeHelloReq) (*ramble.SubscribeHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	if req.Mailbox != "" {
+		if req.Sender != "" {
+			return nil, errors.New("both sender and mailbox given")
+		}
+
+		if !verifyMailbox(req.Mailbox) {
+			return nil, errors.New("mailbox address is invalid")
+		}
+
+		req.Mailbox = strings.ToLower(req.Mailbox)
+	} else if !s.crypto.VerifyFingerprint(req.Sender) {
+		return nil, errors.New("sender fingerprint is invalid")
+	}
+
+	req.Sender = strings.ToLower(req.Sender)
+
+	resp, err := s.newHelloResponse(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := ramble.SubscribeHelloResp(*resp)
+
+	return &ret, nil
+}
+
+// SubscribeVerify processes the verify handshake step. The subscription must
+// be closed once the subscriber is gone.
+func (s *Server) SubscribeVerify(req *ramble.SubscribeVerifyReq) (*Subscription, error) {
+	meta, err := s.verifyReq(req.UUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hello, ok := meta.request.(*ramble.SubscribeHelloReq)
+
+	if !ok {
+		return nil, errors.New("request was not SubscribeHelloReq")
+	}
+
+	if hello.Mailbox != "" {
+		err = verifyMailboxSig(hello.Mailbox, req.Signature,
+			meta.nonce)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return s.subscribe(hello.Mailbox, true)
+	}
+
+	public, err := s.publicKey(hello.Sender)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
+	return s.subscribe(hello.Sender, false)
+}
+
+// Adds a subscription to notices for a fingerprint or mailbox address.
+func (s *Server) subscribe(name string, mailbox bool) (*Subscription, error) {
+	key, lists := name, s.tconvos
+
+	if mailbox {
+		key, lists = mailboxKey(name), s.tmailbox
+	}
+
+	// The conversations are read under the lock, so none can be joined
+	// before the subscription is notified of it.
+	unlock := s.locks.rlock(name)
+	defer unlock()
+
+	convos, err := lists.IndexN(name, 0)
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	c := make(chan ramble.Notice, noticeBuffer)
+	sub := &Subscription{
+		C:   c,
+		c:   c,
+		key: key,
+		s:   s,
+	}
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if s.subs[key] == nil {
+		s.subs[key] = &subscriber{
+			convos: make(map[string]struct{}),
+			subs:   make(map[*Subscription]struct{}),
+		}
+	}
+
+	s.subs[key].subs[sub] = struct{}{}
+	s.join(key, convos...)
+
+	return sub, nil
+}
+
+// Records that a subscribed key is a member of conversations. The caller holds
+// subMu.
+func (s *Server) join(key string, convos ...string) {
+	sb := s.subs[key]
+
+	if sb == nil {
+		return
+	}
+
+	for _, conv := range convos {
+		sb.convos[conv] = struct{}{}
+
+		if s.members[conv] == nil {
+			s.members[conv] = make(map[string]struct{})
+		}
+
+		s.members[conv][key] = struct{}{}
+	}
+}
+
+// Forgets the conversations of a subscribed key. The caller holds subMu.
+func (s *Server) leave(key string) {
+	sb := s.subs[key]
+
+	if sb == nil {
+		return
+	}
+
+	for conv := range sb.convos {
+		delete(s.members[conv], key)
+
+		if len(s.members[conv]) == 0 {
+			delete(s.members, conv)
+		}
+	}
+
+	sb.convos = make(map[string]struct{})
+}
+
+// Stops notifying a key of its conversations once they are deleted.
+func (s *Server) forget(key string) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.leave(key)
+}
+
+// Moves the subscriptions for one key to another, such as when a key is
+// rotated, along with its conversations.
+func (s *Server) moveSubscriptions(from, to string) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	sb := s.subs[from]
+
+	if sb == nil {
+		return
+	}
+
+	convos := make([]string, 0, len(sb.convos))
+
+	for conv := range sb.convos {
+		convos = append(convos, conv)
+	}
+
+	s.leave(from)
+	delete(s.subs, from)
+
+	if s.subs[to] == nil {
+		s.subs[to] = &subscriber{
+			convos: make(map[string]struct{}),
+			subs:   make(map[*Subscription]struct{}),
+		}
+	}
+
+	for sub := range sb.subs {
+		sub.key = to
+		s.subs[to].subs[sub] = struct{}{}
+	}
+
+	s.join(to, convos...)
+}
+
+// Records that keys joined a conversation, then sends a notice to the
+// subscribers of each of its members, dropping it for subscribers which are
+// not keeping up.
+func (s *Server) notify(conv string, keys []string, notice ramble.Notice) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for _, key := range keys {
+		s.join(key, conv)
+	}
+
+	for key := range s.members[conv] {
+		for sub := range s.subs[key].subs {
+			select {
+			case sub.c <- notice:
+			default:
+			}
+		}
+	}
+}
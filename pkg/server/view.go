@@ -1,32 +1,20 @@
 package server
 
 import (
-	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"strings"
 
 	"github.com/esote/ramble"
 	"github.com/esote/ramble/internal/pgp"
-	"github.com/esote/ramble/internal/uuid"
+	"github.com/esote/ramble/internal/store"
 )
 
 // ViewHello processes the hello handshake step.
 func (s *Server) ViewHello(req *ramble.ViewHelloReq) (*ramble.ViewHelloResp, error) {
-	switch req.Type {
-	case ramble.ViewConversations, ramble.ViewMessages:
-		break
-	default:
-		return nil, errors.New("invalid type")
-	}
-
-	if !pgp.VerifyHexFingerprint(req.Sender) {
-		return nil, errors.New("sender fingerprint is invalid")
-	}
-
-	req.Sender = strings.ToLower(req.Sender)
-
-	if req.Count <= 0 {
-		return nil, errors.New("view count <= 0")
+	if err := s.checkView(req); err != nil {
+		return nil, err
 	}
 
 	resp, err := s.newHelloResponse(req)
@@ -42,75 +30,145 @@ func (s *Server) ViewHello(req *ramble.ViewHelloReq) (*ramble.ViewHelloResp, err
 
 // ViewVerify processes the verify handshake step.
 func (s *Server) ViewVerify(req *ramble.ViewVerifyReq) (*ramble.ViewVerifyResp, error) {
+	var list strings.Builder
+
+	if err := s.ViewVerifyTo(req, &list); err != nil {
+		return nil, err
+	}
+
+	return &ramble.ViewVerifyResp{
+		List: list.String(),
+	}, nil
+}
+
+// ViewVerifyTo processes the verify handshake step as ViewVerify does, but
+// writes the list to w as it is read, so large views are not held in memory.
+// Nothing is written unless the request is verified and its list is found, but
+// a failure while writing may leave the list cut short.
+func (s *Server) ViewVerifyTo(req *ramble.ViewVerifyReq, w io.Writer) error {
 	meta, err := s.verifyReq(req.UUID)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	hello, ok := meta.request.(*ramble.ViewHelloReq)
 
 	if !ok {
-		return nil, errors.New("request was not ViewHelloReq")
+		return errors.New("request was not ViewHelloReq")
 	}
 
-	public, err := s.public.Read(hello.Sender)
+	if hello.Mailbox != "" {
+		return s.viewMailbox(hello, req.Signature, meta.nonce, w)
+	}
+
+	public, err := s.publicKey(hello.Sender)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if err = s.verifyReqSig(public, req.Signature, meta.nonce); err != nil {
-		return nil, err
+		return err
 	}
 
-	var buf bytes.Buffer
+	return s.view(hello, public, w)
+}
 
-	switch hello.Type {
-	case ramble.ViewConversations:
-		convos, err := s.tconvos.IndexN(hello.Sender, hello.Count)
+// Checks a view request, normalizing its fingerprint or mailbox address.
+func (s *Server) checkView(req *ramble.ViewHelloReq) error {
+	if err := checkVersion(req.Version); err != nil {
+		return err
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	switch req.Type {
+	case ramble.ViewConversations, ramble.ViewMessages:
+		break
+	default:
+		return errors.New("invalid type")
+	}
 
-		buf.Grow(len(convos) * (uuid.LenUUID + 1))
+	if req.Mailbox != "" {
+		if req.Sender != "" {
+			return errors.New("both sender and mailbox given")
+		}
 
-		for _, convo := range convos {
-			buf.WriteString(convo)
-			buf.Write([]byte{'\n'})
+		if req.Type != ramble.ViewConversations {
+			return errors.New("mailboxes only hold conversations")
 		}
-	case ramble.ViewMessages:
-		msgs, err := s.tmsgs.IndexN(hello.Sender, hello.Count)
 
-		if err != nil {
-			return nil, err
+		if !verifyMailbox(req.Mailbox) {
+			return errors.New("mailbox address is invalid")
 		}
 
-		for _, msgUUID := range msgs {
-			msg, err := s.msg.Read(msgUUID)
+		req.Mailbox = strings.ToLower(req.Mailbox)
+	} else if !s.crypto.VerifyFingerprint(req.Sender) {
+		return errors.New("sender fingerprint is invalid")
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	req.Sender = strings.ToLower(req.Sender)
 
-			buf.Write(msg)
-			buf.Write([]byte{'\n'})
-		}
+	if req.Count <= 0 {
+		return errors.New("view count <= 0")
+	}
+
+	if s.maxCount > 0 && req.Count > s.maxCount {
+		return fmt.Errorf("view count > %d", s.maxCount)
+	}
+
+	return nil
+}
+
+// Views the data of an authorized sender, encrypted with their public key and
+// written to w.
+func (s *Server) view(hello *ramble.ViewHelloReq, public pgp.Key, w io.Writer) error {
+	var index store.Lists
+
+	switch hello.Type {
+	case ramble.ViewConversations:
+		index = s.tconvos
+	case ramble.ViewMessages:
+		index = s.tmsgs
 	default:
-		return nil, errors.New("invalid type")
+		return errors.New("invalid type")
 	}
 
-	p := bytes.NewReader(public)
-	l := bytes.NewReader(buf.Bytes())
+	// Only the index is read under the lock, so that a slow reader does
+	// not hold up writers. Messages are never changed once indexed.
+	unlock := s.locks.rlock(hello.Sender)
+	items, err := index.IndexN(hello.Sender, hello.Count)
+	unlock()
+
+	if err != nil {
+		return err
+	}
 
-	enc, err := pgp.EncryptArmored(p, l)
+	// The list is encrypted as it is read, rather than gathered first.
+	wc, err := public.EncryptWriter(w)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &ramble.ViewVerifyResp{
-		List: string(enc),
-	}, nil
+	for _, item := range items {
+		var b []byte
+
+		if hello.Type == ramble.ViewMessages {
+			if b, err = s.msg.Read(item); err != nil {
+				return err
+			}
+		} else {
+			b = []byte(item)
+		}
+
+		if _, err = wc.Write(b); err != nil {
+			return err
+		}
+
+		if _, err = wc.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+
+	return wc.Close()
 }
@@ -1,9 +1,11 @@
 package server
 
 import (
-	"encoding/hex"
+	"bytes"
 	"errors"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/esote/ramble"
 	"github.com/esote/ramble/internal/pgp"
@@ -11,14 +13,22 @@ import (
 
 // WelcomeHello processes the hello handshake step.
 func (s *Server) WelcomeHello(req *ramble.WelcomeHelloReq) (*ramble.WelcomeHelloResp, error) {
+	if err := checkVersion(req.Version); err != nil {
+		return nil, err
+	}
+
 	public := strings.NewReader(req.Public)
 
-	if ok, err := pgp.VerifyPublicArmored(public); err != nil {
+	if ok, err := s.crypto.VerifyPublic(public); err != nil {
 		return nil, err
 	} else if !ok {
 		return nil, errors.New("input not a public key")
 	}
 
+	if _, _, err := s.checkWelcomeUsable(req.Public); err != nil {
+		return nil, err
+	}
+
 	resp, err := s.newHelloResponse(req)
 
 	if err != nil {
@@ -44,24 +54,103 @@ func (s *Server) WelcomeVerify(req *ramble.WelcomeVerifyReq) (*ramble.WelcomeVer
 		return nil, errors.New("request was not WelcomeHelloReq")
 	}
 
-	err = s.verifyReqSig([]byte(hello.Public), req.Signature, meta.nonce)
+	key, signer, err := s.checkWelcomeUsable(hello.Public)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Revocations are public, so whoever sends one must still sign the
+	// nonce as the owner of the stored key.
+	if err = s.verifyReqSig(signer, req.Signature, meta.nonce); err != nil {
+		return nil, err
+	}
+
 	public := strings.NewReader(hello.Public)
-	fingerprint, err := pgp.FingerprintArmored(public)
+	f, err := s.crypto.Fingerprint(public)
 
 	if err != nil {
 		return nil, errors.New("unable to get public key fingerprint")
 	}
 
-	err = s.public.Write(hex.EncodeToString(fingerprint), []byte(hello.Public))
+	unlock := s.locks.lock(f)
+	defer unlock()
 
-	if err != nil {
+	if err = s.checkRevocationKept(f, key); err != nil {
+		return nil, err
+	}
+
+	if err = s.public.Write(f, []byte(hello.Public)); err != nil {
+		return nil, err
+	}
+
+	s.keys.remove(f)
+
+	// A welcomed key is current, so it no longer points to a rotated key.
+	if err = removeExisting(s.rotated.Remove, f); err != nil {
 		return nil, err
 	}
 
 	return new(ramble.WelcomeVerifyResp), nil
 }
+
+// Checks that a public key may be welcomed, returning the parsed key and the
+// key which signs the nonce. Expired keys are rejected. Revoked keys are
+// accepted only as an update to the same stored key, which signs in place of
+// the revoked key, so that users can revoke their key through the server.
+func (s *Server) checkWelcomeUsable(public string) (key, signer pgp.Key, err error) {
+	key, err = s.crypto.ParseKey(strings.NewReader(public))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = key.VerifyUsable(time.Now())
+
+	if err != pgp.ErrKeyRevoked {
+		return key, key, err
+	}
+
+	fingerprint, err := s.crypto.Fingerprint(strings.NewReader(public))
+
+	if err != nil {
+		return nil, nil, errors.New("unable to get public key" +
+			" fingerprint")
+	}
+
+	signer, err = s.publicKey(fingerprint)
+
+	if err != nil {
+		return nil, nil, errors.New("revoked key is not stored")
+	}
+
+	return key, signer, nil
+}
+
+// Checks that a welcomed key does not replace a stored revoked key with one
+// which is not revoked, since whoever holds the revoked private key could
+// otherwise undo the revocation. The caller holds the fingerprint's lock.
+func (s *Server) checkRevocationKept(fingerprint string, key pgp.Key) error {
+	stored, err := s.public.Read(fingerprint)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	old, err := s.crypto.ParseKey(bytes.NewReader(stored))
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if old.VerifyUsable(now) == pgp.ErrKeyRevoked &&
+		key.VerifyUsable(now) != pgp.ErrKeyRevoked {
+		return errors.New("stored key is revoked")
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package ramble
+
+// RotateHelloReq is sent by the client as the initial request to replace its
+// stored public key with a new one. Conversations and subscriptions of the old
+// key are moved to the new key. Blinded mailboxes and conversation credentials
+// are not linked to keys, so clients keep using those they had before.
+type RotateHelloReq struct {
+	// New public key.
+	Public string `json:"public"`
+
+	// Sender's current public key fingerprint.
+	Sender string `json:"sender"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// RotateHelloResp is sent by the server in response to RotateHelloReq.
+type RotateHelloResp HelloResponse
+
+// RotateVerifyReq is sent by the client in response to RotateHelloResp. The
+// nonce must be signed by both the old and the new key.
+type RotateVerifyReq struct {
+	// NewSignature is the detached signature of the hello response nonce
+	// made with the new key.
+	NewSignature string `json:"new_sig"`
+
+	// Signature is the detached signature of the hello response nonce made
+	// with the old key.
+	Signature string `json:"sig"`
+
+	// UUID from the hello response.
+	UUID string `json:"uuid"`
+}
+
+// RotateVerifyResp is sent by the server in response to RotateVerifyReq and
+// terminates the hello-verify handshake.
+type RotateVerifyResp struct {
+	// Fingerprint of the new public key.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// LookupReq is sent by the client to find the current fingerprint of a key
+// which may have been rotated. Lookups are not authenticated, so they reveal
+// whether a fingerprint is registered.
+type LookupReq struct {
+	// Public key fingerprint.
+	Fingerprint string `json:"fingerprint"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// LookupResp is sent by the server in response to LookupReq.
+type LookupResp struct {
+	// Current public key fingerprint. This is the requested fingerprint if
+	// the key was never rotated.
+	Fingerprint string `json:"fingerprint"`
+}
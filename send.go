@@ -3,19 +3,39 @@ package ramble
 // SendHelloReq is sent by the client as the initial hello request to append a
 // message to a conversion.
 type SendHelloReq struct {
+	// Attachments are the hashes of uploaded attachments the message refers
+	// to.
+	Attachments []string `json:"attachments,omitempty"`
+
 	// Conversation UUID representing a pre-existing conversation, or empty
 	// to start a new conversation.
 	Conversation string `json:"conv"`
 
-	// Message PGP encrypted. The list of encryption recipients should match
-	// the "recipients" member.
+	// Mailboxes are blinded mailbox addresses to add the conversation to,
+	// in place of "recipients". The sender's own mailbox must be included
+	// for the sender to see the conversation.
+	Mailboxes []string `json:"mailboxes,omitempty"`
+
+	// Message PGP encrypted. The message must hold one encrypted session key
+	// for each member of "recipients", or of "mailboxes". Session keys which
+	// do not hide their key ID must belong to a recipient, and all session
+	// keys must hide their key ID when sending to mailboxes.
 	Message string `json:"msg"`
 
-	// Recipients' public key fingerprints.
+	// Recipients' public key fingerprints. Must be empty when sending to
+	// mailboxes.
 	Recipients []string `json:"recipients"`
 
-	// Sender's public key fingerprint.
+	// Sender's public key fingerprint, or empty for a sealed sender. Sealed
+	// senders may start a conversation only with mailboxes. Otherwise they
+	// must give an existing conversation and may only send to its members,
+	// and the verify request signature is then the conversation's
+	// credential. The sender's identity should be put inside the encrypted
+	// message instead.
 	Sender string `json:"sender"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
 }
 
 // SendHelloResp is sent by the server in response to SendHelloReq.
@@ -30,4 +50,9 @@ type SendVerifyResp struct {
 	// Conversation UUID. If the hello request conversation UUID was empty,
 	// this UUID is for the new conversation.
 	Conversation string `json:"conv"`
+
+	// Credential is set when the conversation is created, and authorizes
+	// sealed senders to send to the conversation. It should be shared with
+	// the recipients inside an encrypted message.
+	Credential string `json:"credential,omitempty"`
 }
@@ -0,0 +1,65 @@
+package ramble
+
+import "encoding/json"
+
+// Frame types exchanged within a session.
+const (
+	// FrameHello opens a session. The client sends a SessionHelloReq body,
+	// and the server responds with a SessionHelloResp body.
+	FrameHello = "hello"
+
+	// FrameVerify authenticates a session. The client sends a
+	// SessionVerifyReq body, and the server responds with no body.
+	FrameVerify = "verify"
+
+	// FrameSend sends a message. The client sends a SendHelloReq body,
+	// whose sender is the session's, and the server responds with a
+	// SendVerifyResp body.
+	FrameSend = "send"
+
+	// FrameView views stored data. The client sends a ViewHelloReq body,
+	// whose sender is the session's, and the server responds with a
+	// ViewVerifyResp body.
+	FrameView = "view"
+
+	// FrameDelete deletes stored data. The client sends a DeleteHelloReq
+	// body, whose sender is the session's, and the server responds with no
+	// body.
+	FrameDelete = "delete"
+
+	// FrameNotice is sent by the server with a Notice body when a message
+	// is sent to one of the session's conversations.
+	FrameNotice = "notice"
+)
+
+// Frame is exchanged within a session, which authenticates once with a
+// hello-verify handshake and then multiplexes requests and notices. Responses
+// carry the ID of their request.
+type Frame struct {
+	// Body of the frame, depending on its type.
+	Body json.RawMessage `json:"body,omitempty"`
+
+	// Error is set in responses to failed requests.
+	Error string `json:"error,omitempty"`
+
+	// ID chosen by the client to match responses to requests.
+	ID uint64 `json:"id,omitempty"`
+
+	// Type of the frame.
+	Type string `json:"type"`
+}
+
+// SessionHelloReq is sent by the client to open a session.
+type SessionHelloReq struct {
+	// Sender's public key fingerprint.
+	Sender string `json:"sender"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// SessionHelloResp is sent by the server in response to SessionHelloReq.
+type SessionHelloResp HelloResponse
+
+// SessionVerifyReq is sent by the client in response to SessionHelloResp.
+type SessionVerifyReq VerifyRequest
@@ -0,0 +1,33 @@
+package ramble
+
+// SubscribeHelloReq is sent by the client as the initial request to receive
+// notices of new messages in its conversations.
+type SubscribeHelloReq struct {
+	// Mailbox address of a blinded mailbox to subscribe to, in place of
+	// "sender". The verify request signature is made by MailboxSign.
+	Mailbox string `json:"mailbox,omitempty"`
+
+	// Sender's public key fingerprint.
+	Sender string `json:"sender"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
+}
+
+// SubscribeHelloResp is sent by the server in response to SubscribeHelloReq.
+type SubscribeHelloResp HelloResponse
+
+// SubscribeVerifyReq is sent by the client in response to SubscribeHelloResp.
+// The server then streams notices until the client disconnects.
+type SubscribeVerifyReq VerifyRequest
+
+// Notice is streamed to subscribers when a message is sent to one of their
+// conversations. Notices may be dropped for slow subscribers, who should
+// resynchronize with a view request.
+type Notice struct {
+	// Conversation UUID.
+	Conversation string `json:"conv"`
+
+	// Sequence of the message within the conversation, starting at 0.
+	Sequence uint64 `json:"seq"`
+}
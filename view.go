@@ -15,11 +15,19 @@ type ViewHelloReq struct {
 	// Count of how many items to return, 0 for all.
 	Count uint64 `json:"count"`
 
+	// Mailbox address of a blinded mailbox to view conversations of, in
+	// place of "sender". The verify request signature is made by
+	// MailboxSign.
+	Mailbox string `json:"mailbox,omitempty"`
+
 	// Sender's public key fingerprint.
 	Sender string `json:"sender"`
 
 	// Type of data to view, representing an enumerated type.
 	Type uint8 `json:"type"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
 }
 
 // ViewHelloResp is sent by the server in response to ViewHelloReq.
@@ -31,6 +39,8 @@ type ViewVerifyReq VerifyRequest
 // ViewVerifyResp is sent by the server in response to ViewVerifyReq and
 // terminates the hello-verify handshake. The list items are encrypted with the
 // sender's public key in an amalgamated string using speculative key IDs.
+// Blinded mailbox lists are not encrypted, as the server has no key for them,
+// but are padded with newlines.
 type ViewVerifyResp struct {
 	// List of data.
 	List string `json:"list"`
@@ -4,8 +4,12 @@ package ramble
 // storage. This is required before all other requests, since all other requests
 // initiate based on the sender's fingerprint, not full public key.
 type WelcomeHelloReq struct {
-	// Public key.
+	// Public key. A revoked key may replace the stored key it revokes, in
+	// which case the nonce is signed with the key as stored.
 	Public string `json:"public"`
+
+	// Version of the protocol spoken by the client, or 0 if unspecified.
+	Version uint32 `json:"version,omitempty"`
 }
 
 // WelcomeHelloResp is sent by the server in response to WelcomeHelloReq.